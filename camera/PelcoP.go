@@ -0,0 +1,80 @@
+package camera
+
+import (
+	"camcontrol/camera/pelcod"
+	"camcontrol/camera/pelcop"
+	"errors"
+	"fmt"
+)
+
+// Using protocol Pelco-P (see package pelcop) with the Pelco-D driver (same commands, different frames)
+
+// Pelco-P camera: Pelco-D commands without position queries, absolute positions, power and reset
+// (extended commands of Pelco-D which Pelco-P receivers generally do not implement)
+var pelcoPCapabilities = Capabilities{
+	VariableSpeed: true,
+	Diagonal:      true,
+	Focus:         true,
+	Iris:          true,
+	Backlight:     true,
+	MaxPresets:    pelcoMaxPreset,
+
+	ExposureModes:     []ExposureMode{ExposureAuto, ExposureManual},
+	WhiteBalanceModes: []WhiteBalanceMode{WhiteBalanceAuto, WhiteBalanceManual},
+}
+
+func init() {
+	Register(Driver{
+		Name:        "pelco-p",
+		Description: "Pelco-P camera (9600 baud)",
+		Schema:      Schema{Serial: true, MinAddress: 1, MaxAddress: 0xff},
+		New: func(o Options) (Camera, error) {
+			l, err := o.link(9600, pelcop.ScanFrames)
+			if err != nil {
+				return nil, err
+			}
+			return newPelcoP(l, o.Address)
+		},
+	})
+}
+
+// create camera object for Pelco-P camera (use selected device or search for first recognized device like NewTenveoNV10U),
+// deviceNo starts with 1 like Pelco-D (Pelco-P address = deviceNo - 1)
+func NewPelcoP(port SerialConfig, deviceNo byte) (*camera, error) {
+	if deviceNo < 1 {
+		return nil, fmt.Errorf("invalid Pelco-P device number %d (1..255)", deviceNo)
+	}
+	if err := port.Validate(); err != nil {
+		return nil, err
+	}
+	l, err := newSerialLink(port, 9600, pelcop.ScanFrames)
+	if err != nil {
+		return nil, err
+	}
+	return newPelcoP(l, deviceNo)
+}
+
+// Pelco-P camera on link l (serial port or other transport)
+func newPelcoP(l link, deviceNo byte) (*camera, error) {
+	c := camera{
+		link:         l,
+		deviceNo:     deviceNo,
+		encode:       encodePelcoP,
+		decode:       decodePelcoP,
+		capabilities: pelcoPCapabilities,
+	}
+	return &c, c.connect()
+}
+
+func encodePelcoP(deviceNo byte, cmd pelcod.Command) ([]byte, error) {
+	f, err := pelcop.Encode(deviceNo-1, cmd)
+	if errors.Is(err, pelcop.ErrNotSupported) {
+		return nil, fmt.Errorf("%v %w", err, ErrNotSupported)
+	}
+	return f.Bytes(), err
+}
+
+func decodePelcoP(frame []byte) (byte, pelcod.Command, error) {
+	address, cmd, err := pelcop.Decode(frame)
+	return address + 1, cmd, err
+}
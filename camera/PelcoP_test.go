@@ -0,0 +1,18 @@
+package camera
+
+import (
+	"camcontrol/camera/pelcod"
+	"encoding/hex"
+	"testing"
+)
+
+// device number 1..255 of the application is the 0-based Pelco-P address
+func TestPelcoPAddress(t *testing.T) {
+	b, err := encodePelcoP(1, pelcod.Move{Pan: pelcod.PanLeft, PanSpeed: 0x20})
+	if err != nil || hex.EncodeToString(b) != "a00000042000af2b" {
+		t.Errorf("encodePelcoP(1) = %x, %v", b, err)
+	}
+	if deviceNo, _, err := decodePelcoP(b); deviceNo != 1 || err != nil {
+		t.Errorf("decodePelcoP(%x) = %d, %v, want 1", b, deviceNo, err)
+	}
+}
@@ -1,271 +1,428 @@
 package camera
 
 import (
-	"camcontrol/device"
-	"encoding/hex"
+	"camcontrol/camera/internal/position"
+	"camcontrol/camera/pelcod"
 	"fmt"
-	"io"
 	"log"
-	"os"
-	"strconv"
-	"strings"
+	"math"
 	"time"
-
-	"github.com/jacobsa/go-serial/serial"
 )
 
-// Using protocol Pelco-D - unfortunately there are several issues and many commands do not work with Tenveo camera!
+// Using protocol Pelco-D (see package pelcod) - unfortunately there are several issues and many commands do not work with Tenveo camera!
+// Therefore the Tenveo NV10U is a profile of the driver (newTenveoNV10U), other Pelco-D cameras use the standard profile (newPelcoD).
+
+// The driver is used for Pelco-P as well (see PelcoP.go), the frames are built by the encode function.
+
+const (
+	pelcoTimeout   = 500 * time.Millisecond // deadline of query responses
+	pelcoIrisStep  = 200 * time.Millisecond // iris moves until stop, a step is a short move
+	pelcoMaxPreset = 0xff
+)
 
 type camera struct {
-	deviceNo   byte               // camera device number (used to address target camera, mutliple camera could be used according protocol)
-	portNo     int                // COM port number to use (configured one or -1 = search for first "USB-SERIAL CH340")
-	port       io.ReadWriteCloser // serial port access
-	simulation bool
+	link
+	deviceNo     byte                                                    // camera device number (used to address target camera, mutliple camera could be used according protocol)
+	encode       func(deviceNo byte, cmd pelcod.Command) ([]byte, error) // build frame of protocol
+	decode       func(frame []byte) (byte, pelcod.Command, error)        // parse response frame (device number, command)
+	tenveo       bool                                                    // zoom speed in data 1 (pan speed) instead of "set zoom speed" command
+	capabilities Capabilities                                            // functions of the camera model or protocol
 }
 
-// creatre camera object for Tenveo VN10U camera (use given device or search for first recognized device,
-// e.g. windows assigns new port using different USB connector)
-func NewTenveoNV10U(portNo int, deviceNo byte) (*camera, error) {
-	log.Printf("'camera-control' setting: %v", os.Getenv("camera-control"))
-	c := camera{
-		portNo:     portNo,
-		deviceNo:   deviceNo,
-		simulation: os.Getenv("camera-control") == "simulation",
-	}
-	return &c, c.connect()
+// standard Pelco-D camera: all commands of the protocol
+var pelcoDCapabilities = Capabilities{
+	VariableSpeed: true,
+	Diagonal:      true,
+	Focus:         true,
+	Iris:          true,
+	Backlight:     true,
+	AbsoluteMove:  true,
+	PositionQuery: true,
+	Power:         true,
+	Reset:         true,
+	MaxPresets:    pelcoMaxPreset,
+
+	ExposureModes:     []ExposureMode{ExposureAuto, ExposureManual},
+	WhiteBalanceModes: []WhiteBalanceMode{WhiteBalanceAuto, WhiteBalanceManual},
+}
+
+// Tenveo NV10U: only pan/tilt/zoom and presets are known to work
+var tenveoCapabilities = Capabilities{VariableSpeed: true, Diagonal: true, MaxPresets: pelcoMaxPreset}
+
+func init() {
+	Register(Driver{
+		Name:        "pelco-d",
+		Description: "Pelco-D camera (2400 baud)",
+		Schema:      Schema{Serial: true, MinAddress: 1, MaxAddress: 0xff},
+		New: func(o Options) (Camera, error) {
+			l, err := o.link(2400, pelcod.ScanFrames)
+			if err != nil {
+				return nil, err
+			}
+			return newPelcoD(l, o.Address)
+		},
+	})
+	Register(Driver{
+		Name:        "tenveo-nv10u",
+		Description: "Tenveo NV10U, Pelco-D with off-spec zoom speed, pan/tilt/zoom and presets only (38400 baud)",
+		Schema:      Schema{Serial: true, MinAddress: 1, MaxAddress: 0xff},
+		New: func(o Options) (Camera, error) {
+			l, err := o.link(38400, pelcod.ScanFrames)
+			if err != nil {
+				return nil, err
+			}
+			return newTenveoNV10U(l, o.Address)
+		},
+	})
 }
 
-func (c *camera) connect() (err error) {
-	if c.simulation {
-		return nil
+// create camera object for standard Pelco-D camera (use selected device or search for first recognized device like NewTenveoNV10U)
+func NewPelcoD(port SerialConfig, deviceNo byte) (*camera, error) {
+	if err := port.Validate(); err != nil {
+		return nil, err
 	}
-	c.Close()
-	portNo := getSerialPort(c.portNo)
-	// search for COM0..COM255
-	i := 0
-	maxPort := 255
-
-	var options serial.OpenOptions
-	if portNo != -1 {
-		i = portNo
-		maxPort = i
+	l, err := newSerialLink(port, 2400, pelcod.ScanFrames)
+	if err != nil {
+		return nil, err
 	}
-	for i <= maxPort {
-		options = serial.OpenOptions{
-			PortName:        "COM" + strconv.Itoa(i),
-			BaudRate:        38400,
-			DataBits:        8,
-			StopBits:        1,
-			MinimumReadSize: 1,
-		}
+	return newPelcoD(l, deviceNo)
+}
 
-		// Open the port.
-		c.port, err = serial.Open(options)
-		if err == nil {
-			break
-		}
-		i++
+// Pelco-D camera on link l (serial port or other transport)
+func newPelcoD(l link, deviceNo byte) (*camera, error) {
+	c := camera{
+		link:         l,
+		deviceNo:     deviceNo,
+		encode:       encodePelcoD,
+		decode:       pelcod.Decode,
+		capabilities: pelcoDCapabilities,
 	}
+	return &c, c.connect()
+}
+
+// creatre camera object for Tenveo VN10U camera (use selected device or search for first recognized device,
+// e.g. windows assigns new port using different USB connector)
+func NewTenveoNV10U(port SerialConfig, deviceNo byte) (*camera, error) {
+	if err := port.Validate(); err != nil {
+		return nil, err
+	}
+	l, err := newSerialLink(port, 38400, pelcod.ScanFrames)
 	if err != nil {
-		portName := "COM"
-		if portNo == -1 {
-			portName += "0.." + strconv.Itoa(maxPort)
-		} else {
-			portName += strconv.Itoa(portNo)
-		}
-		err = fmt.Errorf("serial.Open: failed! port %v (%v)", portName, err)
-		c.port = nil
-	} else {
-		log.Printf("serial.Open use port %v", options.PortName)
+		return nil, err
 	}
-	return
+	return newTenveoNV10U(l, deviceNo)
 }
 
-// search for device name "USB-SERIAL CH34<x> (COM<portNo>)"
-func getSerialPort(portNo int) int {
-	device.Init()
-	defer device.Exit()
-	prefix := "(COM"
-	postfix := ")"
-	devName := "USB-SERIAL CH34"
-	for _, device := range device.GetDeviceClassPortNameList() {
-		if strings.Contains(device, devName) {
-			start := strings.Index(device, prefix)
-			if start < 0 {
-				continue
-			}
-			start += len(prefix)
-			end := strings.Index(device[start:], postfix)
-			if end < 0 {
-				continue
-			}
-			end += start
-			port, err := strconv.Atoi(device[start:end])
-			if err != nil {
-				log.Printf("Failed to read COM port in devicename: %v\n", device)
-				continue
-			}
-			if portNo == -1 || portNo == port {
-				return port
-			}
-		}
+// Tenveo NV10U camera on link l: Pelco-D with off-spec zoom speed and few working commands
+func newTenveoNV10U(l link, deviceNo byte) (*camera, error) {
+	c := camera{
+		link:         l,
+		deviceNo:     deviceNo,
+		encode:       encodePelcoD,
+		decode:       pelcod.Decode,
+		tenveo:       true,
+		capabilities: tenveoCapabilities,
 	}
-	log.Printf("Failed to find device with name %s\n", devName)
-	return -1
+	return &c, c.connect()
 }
 
-func (c *camera) Close() {
-	if c.port != nil {
-		_ = c.port.Close()
-		c.port = nil
-	}
+func encodePelcoD(deviceNo byte, cmd pelcod.Command) ([]byte, error) {
+	return pelcod.Encode(deviceNo, cmd).Bytes(), nil
 }
 
-const pan = 0
-const tilt = 0
+func (c *camera) Watch(notify func(connected bool)) error {
+	return watchLink(c.link, notify)
+}
 
-func (c *camera) Up() error {
-	log.Println("Cam up")
-	return c.sendCommand([]byte{0x00, 0x08, 0x00, tilt})
+// functions of the camera model (Tenveo) or protocol (Pelco-D, Pelco-P)
+func (c *camera) Capabilities() Capabilities {
+	return c.capabilities
 }
 
-func (c *camera) Down() error {
-	log.Println("Cam down")
-	return c.sendCommand([]byte{0x00, 0x10, 0x00, tilt})
+// pan/tilt speed 0 means no movement for Pelco-D receivers, therefore the lowest speed is 1 (like VISCA)
+
+func (c *camera) Up(speed float64) error {
+	log.Printf("Cam up %.2f\n", speed)
+	return c.sendCommand(pelcod.Move{Tilt: pelcod.TiltUp, TiltSpeed: scaleSpeed(speed, 1, pelcod.MaxSpeed)})
 }
 
-func (c *camera) Left() error {
-	log.Println("Cam left")
-	return c.sendCommand([]byte{0x00, 0x04, pan, 0x00})
+func (c *camera) Down(speed float64) error {
+	log.Printf("Cam down %.2f\n", speed)
+	return c.sendCommand(pelcod.Move{Tilt: pelcod.TiltDown, TiltSpeed: scaleSpeed(speed, 1, pelcod.MaxSpeed)})
 }
 
-func (c *camera) Right() error {
-	log.Println("Cam right")
-	return c.sendCommand([]byte{0x00, 0x02, pan, 0x00})
+func (c *camera) Left(speed float64) error {
+	log.Printf("Cam left %.2f\n", speed)
+	return c.sendCommand(pelcod.Move{Pan: pelcod.PanLeft, PanSpeed: scaleSpeed(speed, 1, pelcod.MaxSpeed)})
+}
+
+func (c *camera) Right(speed float64) error {
+	log.Printf("Cam right %.2f\n", speed)
+	return c.sendCommand(pelcod.Move{Pan: pelcod.PanRight, PanSpeed: scaleSpeed(speed, 1, pelcod.MaxSpeed)})
+}
+
+// Tenveo reads the zoom speed of data 1 (pan speed), therefore zoom speed is ignored while panning
+func (c *camera) Move(pan, tilt, zoom float64) error {
+	log.Printf("Cam move %.2f %.2f %.2f\n", pan, tilt, zoom)
+	m := pelcod.Move{
+		Pan:       pelcod.Direction(sign(pan)),
+		Tilt:      pelcod.Direction(sign(tilt)),
+		Zoom:      pelcod.Direction(sign(zoom)),
+		PanSpeed:  scaleSpeed(math.Abs(pan), 1, pelcod.MaxSpeed),
+		TiltSpeed: scaleSpeed(math.Abs(tilt), 1, pelcod.MaxSpeed),
+	}
+	if c.tenveo {
+		if pan == 0 {
+			m.PanSpeed = scaleSpeed(math.Abs(zoom), 1, pelcod.MaxSpeed)
+		}
+	} else if zoom != 0 {
+		err := c.sendCommand(pelcod.SetZoomSpeed{Speed: scaleSpeed(math.Abs(zoom), 0, pelcod.MaxZoomSpeed)})
+		if err != nil {
+			return err
+		}
+	}
+	return c.sendCommand(m)
 }
 
 func (c *camera) PtStop() error {
 	log.Println("Cam stop pt")
-	return c.sendCommand([]byte{0x00, 0x00, 0x00, 0x00})
+	return c.sendCommand(pelcod.Move{})
 }
 
 func (c *camera) ZoomIn(speed byte) error {
 	log.Println("Cam zoom in")
-	return c.sendCommand([]byte{0x00, 0x20, speed, 0x00})
+	return c.zoom(pelcod.ZoomTele, speed)
 }
 
 func (c *camera) ZoomOut(speed byte) error {
 	log.Println("Cam zoom out")
-	return c.sendCommand([]byte{0x00, 0x40, speed, 0x00})
+	return c.zoom(pelcod.ZoomWide, speed)
+}
+
+// Tenveo reads the zoom speed of data 1 (pan speed) - not according spec,
+// otherwise zoom speed 0..0x3f is scaled to "set zoom speed" range
+func (c *camera) zoom(direction pelcod.Direction, speed byte) error {
+	if c.tenveo {
+		return c.sendCommand(pelcod.Move{Zoom: direction, PanSpeed: scaleSpeed(float64(speed)/pelcod.MaxSpeed, 1, pelcod.MaxSpeed)})
+	}
+	err := c.sendCommand(pelcod.SetZoomSpeed{Speed: scaleSpeed(float64(speed)/pelcod.MaxSpeed, 0, pelcod.MaxZoomSpeed)})
+	if err != nil {
+		return err
+	}
+	return c.sendCommand(pelcod.Move{Zoom: direction})
 }
 
 func (c *camera) ZoomStop() error {
 	log.Println("Cam zoom stop")
-	return c.sendCommand([]byte{0x00, 0x00, 0x00, 0x00})
+	return c.sendCommand(pelcod.Move{})
+}
+
+func (c *camera) FocusNear(speed byte) error {
+	log.Println("Cam focus near")
+	return c.focus(pelcod.FocusNear, speed)
 }
 
-/*
-func (c *camera) FocusIn(speed byte) error {
-	log.Println("Cam focus in")
-	return c.sendCommand([]byte{0x01, 0x00, 0x00, 0x00})
+func (c *camera) FocusFar(speed byte) error {
+	log.Println("Cam focus far")
+	return c.focus(pelcod.FocusFar, speed)
 }
 
-func (c *camera) FocusOut(speed byte) error{
-	log.Println("Cam focus out")
-	return c.sendCommand([]byte{0x00, 0x80, 0x00, 0x00})
+// focus speed 0..0x3f is scaled to "set focus speed" range
+func (c *camera) focus(direction pelcod.Direction, speed byte) error {
+	err := c.sendCommand(pelcod.SetFocusSpeed{Speed: scaleSpeed(float64(speed)/pelcod.MaxSpeed, 0, pelcod.MaxFocusSpeed)})
+	if err != nil {
+		return err
+	}
+	return c.sendCommand(pelcod.Move{Focus: direction})
 }
 
 func (c *camera) FocusStop() error {
 	log.Println("Cam focus stop")
-	return c.sendCommand([]byte{0x00, 0x00, 0x00, 0x00})
+	return c.sendCommand(pelcod.Move{})
 }
 
 func (c *camera) FocusAuto() error {
 	log.Println("Cam focus auto")
-	return c.sendCommand([]byte{0x10, 0x00, 0x00, 0x00})
+	return c.sendCommand(pelcod.AutoFocus{Mode: pelcod.ModeAuto})
 }
 
 func (c *camera) FocusManual() error {
 	log.Println("Cam focus manual")
-	return c.sendCommand([]byte{0x10, 0x00, 0x00, 0x00})
+	return c.sendCommand(pelcod.AutoFocus{Mode: pelcod.ModeOff})
+}
+
+// Pelco-D has no one-push focus
+func (c *camera) FocusOnePush() error {
+	return ErrNotSupported
+}
+
+func (c *camera) IrisOpen() error {
+	log.Println("Cam iris open")
+	return c.irisStep(pelcod.IrisOpen)
+}
+
+func (c *camera) IrisClose() error {
+	log.Println("Cam iris close")
+	return c.irisStep(pelcod.IrisClose)
+}
+
+func (c *camera) irisStep(direction pelcod.Direction) error {
+	if err := c.sendCommand(pelcod.Move{Iris: direction}); err != nil {
+		return err
+	}
+	time.Sleep(pelcoIrisStep)
+	return c.sendCommand(pelcod.Move{})
+}
+
+// Pelco-D has automatic gain control only (see Exposure)
+func (c *camera) GainUp() error {
+	return ErrNotSupported
+}
+
+func (c *camera) GainDown() error {
+	return ErrNotSupported
+}
+
+// auto iris and AGC on (auto) or off (manual)
+func (c *camera) Exposure(mode ExposureMode) error {
+	log.Printf("Cam exposure %v\n", mode)
+	var m pelcod.Mode
+	switch mode {
+	case ExposureAuto:
+		m = pelcod.ModeAuto
+	case ExposureManual:
+		m = pelcod.ModeOff
+	default:
+		return fmt.Errorf("exposure mode %v %w", mode, ErrNotSupported)
+	}
+	if err := c.sendCommand(pelcod.AutoIris{Mode: m}); err != nil {
+		return err
+	}
+	return c.sendCommand(pelcod.AGC{Mode: m})
+}
+
+func (c *camera) Backlight(on bool) error {
+	log.Printf("Cam backlight %v\n", on)
+	return c.sendCommand(pelcod.Backlight{On: on})
+}
+
+func (c *camera) WhiteBalance(mode WhiteBalanceMode) error {
+	log.Printf("Cam white balance %v\n", mode)
+	switch mode {
+	case WhiteBalanceAuto:
+		return c.sendCommand(pelcod.AutoWhiteBalance{On: true})
+	case WhiteBalanceManual:
+		return c.sendCommand(pelcod.AutoWhiteBalance{On: false})
+	}
+	return fmt.Errorf("white balance mode %v %w", mode, ErrNotSupported)
+}
+
+func (c *camera) WhiteBalanceTrigger() error {
+	return ErrNotSupported
 }
-*/
 
 func (c *camera) PresetSelect(preset byte) error {
 	log.Printf("Cam preset select %d\n", preset)
-	return c.sendCommand([]byte{0x00, 0x07, 0x00, preset})
+	return c.sendCommand(pelcod.GotoPreset{Preset: preset})
 }
 
 func (c *camera) PresetSave(preset byte) error {
 	log.Printf("Cam preset save %d\n", preset)
-	return c.sendCommand([]byte{0x00, 0x03, 0x00, preset})
+	return c.sendCommand(pelcod.SetPreset{Preset: preset})
 }
 
 func (c *camera) PresetReset(preset byte) error {
 	log.Println("Cam preset reset")
-	return c.sendCommand([]byte{0x00, 0x05, 0x00, preset})
+	return c.sendCommand(pelcod.ClearPreset{Preset: preset})
 }
 
-func calcChecksum(msg []byte) (checksum byte) {
-	for _, v := range msg[1:] {
-		checksum += v
+// query pan, tilt and zoom position (pan 0..35999 hundredths of degree clockwise, tilt hundredths of degree
+// with 0..9000 below and 27000..35999 above horizon, zoom 0..65535 = wide..tele)
+func (c *camera) Position() (p Position, err error) {
+	pan, err := c.query(pelcod.QueryPan{})
+	if err != nil {
+		return p, err
 	}
-	return
+	tilt, err := c.query(pelcod.QueryTilt{})
+	if err != nil {
+		return p, err
+	}
+	zoom, err := c.query(pelcod.QueryZoom{})
+	if err != nil {
+		return p, err
+	}
+	p.RawPan, p.RawTilt, p.RawZoom = float64(pan), float64(tilt), float64(zoom)
+	p.Pan = position.Normalize(position.SignedDegrees(p.RawPan), -180, 180)
+	p.Tilt = position.Normalize(-position.SignedDegrees(p.RawTilt), -90, 90)
+	p.Zoom = p.RawZoom / math.MaxUint16
+	return p, nil
 }
 
-// send command according protocol
-func (c *camera) sendCommand(cmd []byte) error {
-	header := []byte{0xff, c.deviceNo}
-	msg := append(header, cmd...)
-	msg = append(msg, calcChecksum(msg))
-
-	if c.port == nil {
-		err := c.connect()
-		if err != nil {
-			return fmt.Errorf("failed writing to port: %v", err)
-		}
+func (c *camera) AbsoluteMove(pan, tilt, zoom float64) error {
+	log.Printf("Cam absolute move %.2f %.2f %.2f\n", pan, tilt, zoom)
+	if err := c.sendCommand(pelcod.SetPanPosition{Position: position.Hundredths(position.Denormalize(pan, -180, 180))}); err != nil {
+		return err
 	}
-	if c.port != nil {
-		n, err := c.port.Write(msg)
-		if err != nil {
-			log.Printf("Failed writing to port: %v, try reconnect...", err)
-			err := c.connect()
-			if err != nil {
-				return fmt.Errorf("failed writing to port: %v", err)
-			}
-			n, err = c.port.Write(msg)
-			if err != nil {
-				return fmt.Errorf("failed writing to port: %v", err)
-			}
-		}
-		log.Printf("Wrote %d bytes: %s\n", n, hex.EncodeToString(msg))
-		log.Printf("Response : %s\n", hex.EncodeToString(c.readResponse()))
-	} else {
-		log.Printf("Simulate request: %s\n", hex.EncodeToString(msg))
+	if err := c.sendCommand(pelcod.SetTiltPosition{Position: position.Hundredths(-position.Denormalize(tilt, -90, 90))}); err != nil {
+		return err
 	}
-	return nil
+	zoom = math.Max(0, math.Min(1, zoom))
+	return c.sendCommand(pelcod.SetZoomPosition{Position: uint16(math.Round(zoom * math.MaxUint16))})
 }
 
-// read response (only for debugging, mostly there is no response and if, it is not according spec)
-func (c *camera) readResponse() []byte {
-	response := []byte{}
-	if c.port == nil {
-		return response
+func (c *camera) Power(on bool) error {
+	log.Printf("Cam power %v\n", on)
+	return c.sendCommand(pelcod.Power{On: on})
+}
+
+func (c *camera) Reset() error {
+	log.Println("Cam remote reset")
+	return c.sendCommand(pelcod.RemoteReset{})
+}
+
+// send position query and return the position of the response (0 in simulation)
+func (c *camera) query(cmd pelcod.Command) (position uint16, err error) {
+	msg, err := c.encode(c.deviceNo, cmd)
+	if err != nil {
+		return 0, err
 	}
-	received := true
-	for {
-		val := make([]byte, 1)
-		n, _ := c.port.Read(val)
-		if n > 0 {
-			response = append(response, val...)
-			received = true
-		} else {
-			if received {
-				time.Sleep(10 * time.Millisecond)
-				received = false
-			} else {
-				return response
-			}
+	_, err = c.send(msg, response{timeout: pelcoTimeout, done: func(frame []byte) bool {
+		deviceNo, r, err := c.decode(frame)
+		if err != nil || deviceNo != c.deviceNo {
+			return false
+		}
+		p, ok := positionResponse(cmd, r)
+		if ok {
+			position = p
 		}
+		return ok
+	}})
+	return
+}
+
+// position of response r to query cmd (false = r is no response to cmd, e.g. response of a previous query)
+func positionResponse(cmd, r pelcod.Command) (uint16, bool) {
+	var ok bool
+	switch r := r.(type) {
+	case pelcod.PanPosition:
+		_, ok = cmd.(pelcod.QueryPan)
+		return r.Position, ok
+	case pelcod.TiltPosition:
+		_, ok = cmd.(pelcod.QueryTilt)
+		return r.Position, ok
+	case pelcod.ZoomPosition:
+		_, ok = cmd.(pelcod.QueryZoom)
+		return r.Position, ok
+	}
+	return 0, false
+}
+
+// send command according protocol (responses are not awaited, they are discarded before the next command)
+func (c *camera) sendCommand(cmd pelcod.Command) error {
+	msg, err := c.encode(c.deviceNo, cmd)
+	if err != nil {
+		return err
 	}
+	_, err = c.send(msg, response{})
+	return err
 }
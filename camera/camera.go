@@ -1,27 +1,178 @@
 package camera
 
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrNotSupported is returned for functions the camera or protocol does not provide
+var ErrNotSupported = errors.New("not supported by camera")
+
+// Camera is the protocol independent access to a camera
+// (pan/tilt speed is normalized 0..1 = slowest..fastest, zoom speed is given in protocol range)
 type Camera interface {
 	Close()
 
-	Up() error
-	Down() error
-	Left() error
-	Right() error
+	// functions supported by the camera (e.g. to hide controls)
+	Capabilities() Capabilities
+
+	Up(speed float64) error
+	Down(speed float64) error
+	Left(speed float64) error
+	Right(speed float64) error
 	PtStop() error
 
+	// combined pan/tilt/zoom move, values -1..1: sign is direction (right, up, zoom in), magnitude is speed, 0 stops axis
+	Move(pan, tilt, zoom float64) error
+
 	ZoomIn(speed byte) error
 	ZoomOut(speed byte) error
 	ZoomStop() error
 
-	/* currently not used because not supported by cam
-	FocusIn(speed byte) error
-	FocusOut(speed byte) error
+	// manual focus with speed in protocol range (like zoom), the camera has to be in manual focus mode
+	FocusNear(speed byte) error
+	FocusFar(speed byte) error
 	FocusStop() error
 
 	FocusAuto() error
 	FocusManual() error
-	*/
+	// single auto focus in manual focus mode
+	FocusOnePush() error
+
+	// image: iris and gain in steps (manual exposure)
+	IrisOpen() error
+	IrisClose() error
+	GainUp() error
+	GainDown() error
+	Exposure(mode ExposureMode) error
+	Backlight(on bool) error
+	WhiteBalance(mode WhiteBalanceMode) error
+	// adjust white balance once (WhiteBalanceOnePush)
+	WhiteBalanceTrigger() error
 
 	PresetSelect(preset byte) error
 	PresetSave(preset byte) error
+
+	// switch camera on or off (standby for most cameras)
+	Power(on bool) error
+	// restart camera (remote reset)
+	Reset() error
+
+	// current pan/tilt/zoom position (ErrNotSupported if camera can't be queried)
+	Position() (Position, error)
+	// move to normalized position (see Position), returns before the camera reached the position
+	AbsoluteMove(pan, tilt, zoom float64) error
+}
+
+// Capabilities of a camera driver, functions which are not supported return ErrNotSupported or have no effect
+type Capabilities struct {
+	VariableSpeed bool `json:"variableSpeed"` // pan/tilt/zoom speed is used
+	Diagonal      bool `json:"diagonal"`      // combined pan/tilt move
+	Focus         bool `json:"focus"`         // near/far, auto/manual
+	OnePushFocus  bool `json:"onePushFocus"`  // single auto focus
+	Iris          bool `json:"iris"`
+	Gain          bool `json:"gain"`
+	Backlight     bool `json:"backlight"`
+	AbsoluteMove  bool `json:"absoluteMove"`
+	PositionQuery bool `json:"positionQuery"`
+	Power         bool `json:"power"`
+	Reset         bool `json:"reset"`
+	MaxPresets    int  `json:"maxPresets"` // highest preset number (0 = unknown)
+
+	ExposureModes     []ExposureMode     `json:"exposureModes"`     // empty = not supported
+	WhiteBalanceModes []WhiteBalanceMode `json:"whiteBalanceModes"` // empty = not supported
+}
+
+// ExposureMode of the camera
+type ExposureMode string
+
+const (
+	ExposureAuto            ExposureMode = "auto"
+	ExposureManual          ExposureMode = "manual" // iris and gain are set manually
+	ExposureShutterPriority ExposureMode = "shutter"
+	ExposureIrisPriority    ExposureMode = "iris"
+)
+
+// WhiteBalanceMode of the camera
+type WhiteBalanceMode string
+
+const (
+	WhiteBalanceAuto    WhiteBalanceMode = "auto"
+	WhiteBalanceIndoor  WhiteBalanceMode = "indoor"
+	WhiteBalanceOutdoor WhiteBalanceMode = "outdoor"
+	WhiteBalanceOnePush WhiteBalanceMode = "onepush" // adjusted by WhiteBalanceTrigger
+	WhiteBalanceManual  WhiteBalanceMode = "manual"
+)
+
+// Position of the camera, normalized: pan/tilt -1..1 (left/down limit..0..right/up limit), zoom 0..1 (wide..tele),
+// raw values are in protocol units (e.g. hundredths of degree for Pelco-D)
+type Position struct {
+	Pan     float64 `json:"pan"`
+	Tilt    float64 `json:"tilt"`
+	Zoom    float64 `json:"zoom"`
+	RawPan  float64 `json:"rawPan"`
+	RawTilt float64 `json:"rawTilt"`
+	RawZoom float64 `json:"rawZoom"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("pan %+.2f tilt %+.2f zoom %.2f", p.Pan, p.Tilt, p.Zoom)
+}
+
+// ConnectionWatcher is implemented by cameras which reconnect in the background (e.g. USB adapter plugged in again)
+type ConnectionWatcher interface {
+	// Watch starts watching, notify is called with the current state and on every change
+	Watch(notify func(connected bool)) error
+}
+
+// link carries the encoded protocol messages to the camera (serial port or network)
+type link interface {
+	connect() error
+	// write message and return the response frames of the camera (TimeoutError if response is not complete)
+	send(msg []byte, r response) ([][]byte, error)
+	Close()
+}
+
+// response is the expected response of a message
+type response struct {
+	timeout time.Duration           // deadline of the response, 0 = no response expected
+	done    func(frame []byte) bool // last frame of the response, nil = first frame
+}
+
+// run init of the protocol once per link, e.g. addressing a VISCA daisy chain shared by several cameras
+// (other links run it for every camera)
+func initLink(l link, init func() error) error {
+	if t, ok := l.(*transportLink); ok {
+		return t.initOnce(init)
+	}
+	return init()
+}
+
+// start connection watcher of link (transport links only)
+func watchLink(l link, notify func(connected bool)) error {
+	if w, ok := l.(interface {
+		watch(notify func(connected bool)) error
+	}); ok {
+		return w.watch(notify)
+	}
+	return fmt.Errorf("connection watcher %w", ErrNotSupported)
+}
+
+// scale normalized speed 0..1 to protocol speed range min..max
+func scaleSpeed(speed float64, min, max byte) byte {
+	speed = math.Max(0, math.Min(1, speed))
+	return min + byte(math.Round(speed*float64(max-min)))
+}
+
+// direction of normalized value (-1, 0, 1)
+func sign(v float64) int8 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
 }
@@ -0,0 +1,145 @@
+package camera
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// drivers register by protocol name (see init of the driver files), the application creates cameras
+// of the configured protocol with New, e.g. New("visca", Options{Serial: port, Address: 1})
+
+// Options configures a camera, the schema of the driver tells which options are used
+type Options struct {
+	Serial    SerialConfig // serial port with line parameters
+	Transport Transport    // transport of the protocol instead of serial port (nil = Serial)
+	Address   byte         // device address of camera on the bus
+	Host      string       // network address (host[:port] or URL)
+	Vendor    string       // command set of camera, e.g. HTTP CGI vendor
+	User      string
+	Password  string
+}
+
+// Schema describes the options used by a driver (unused options are ignored)
+type Schema struct {
+	Serial     bool // Options.Serial or Options.Transport is used (protocol runs on every transport)
+	Host       bool // Options.Host is required
+	Vendor     bool // Options.Vendor is required
+	Auth       bool // Options.User and Options.Password are used
+	MinAddress byte // range of Options.Address (MaxAddress 0 = no address)
+	MaxAddress byte
+}
+
+// Driver creates cameras of a protocol
+type Driver struct {
+	Name        string // protocol name, e.g. "pelco-d"
+	Description string
+	Schema      Schema
+	New         func(o Options) (Camera, error) // camera is returned with error if it may connect later
+}
+
+var (
+	drivers   = map[string]Driver{}
+	driversMu sync.Mutex
+)
+
+// Register adds a driver (panics on duplicate or empty name like other registries)
+func Register(d Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	name := strings.ToLower(d.Name)
+	if name == "" || d.New == nil {
+		panic("camera: Register of invalid driver")
+	}
+	if _, ok := drivers[name]; ok {
+		panic("camera: Register called twice for driver " + name)
+	}
+	drivers[name] = d
+}
+
+// Drivers returns the names of the registered drivers (sorted)
+func Drivers() []string {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupDriver returns the driver of protocol name (case insensitive)
+func LookupDriver(name string) (Driver, bool) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	d, ok := drivers[strings.ToLower(name)]
+	return d, ok
+}
+
+// New creates a camera of protocol name with options o (validated by the schema of the driver)
+func New(name string, o Options) (Camera, error) {
+	d, ok := LookupDriver(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown camera protocol '%v' (%v)", name, strings.Join(Drivers(), ", "))
+	}
+	if err := d.Schema.Validate(o); err != nil {
+		return nil, fmt.Errorf("%v: %v", d.Name, err)
+	}
+	return d.New(o)
+}
+
+// Validate checks the options required by the schema
+func (s Schema) Validate(o Options) error {
+	if s.Serial {
+		if err := o.Serial.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.Host && o.Host == "" {
+		return fmt.Errorf("no camera host given")
+	}
+	if s.Vendor && o.Vendor == "" {
+		return fmt.Errorf("no camera vendor given")
+	}
+	if s.MaxAddress > 0 && (o.Address < s.MinAddress || o.Address > s.MaxAddress) {
+		return fmt.Errorf("invalid camera address %d (%d..%d)", o.Address, s.MinAddress, s.MaxAddress)
+	}
+	return nil
+}
+
+// String lists the options of the schema, e.g. "serial, address 1..255"
+func (s Schema) String() string {
+	var opts []string
+	if s.Serial {
+		opts = append(opts, "serial")
+	}
+	if s.Host {
+		opts = append(opts, "host")
+	}
+	if s.Vendor {
+		opts = append(opts, "vendor")
+	}
+	if s.Auth {
+		opts = append(opts, "user", "password")
+	}
+	if s.MaxAddress > 0 {
+		opts = append(opts, fmt.Sprintf("address %d..%d", s.MinAddress, s.MaxAddress))
+	}
+	return strings.Join(opts, ", ")
+}
+
+// link of a driver on Options.Transport or on the serial port (baudRate and split of the protocol),
+// read timeout, frame delay and line parameters (serial server) of Options.Serial apply to every transport
+func (o Options) link(baudRate uint, split bufio.SplitFunc) (link, error) {
+	if o.Transport != nil {
+		config := o.Serial.withDefaults(baudRate)
+		if t, ok := o.Transport.(interface{ configure(SerialConfig) }); ok {
+			t.configure(config)
+		}
+		return newTransportLink(o.Transport, split, config.FrameDelay, config.ReadTimeout)
+	}
+	return newSerialLink(o.Serial, baudRate, split)
+}
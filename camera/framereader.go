@@ -0,0 +1,169 @@
+package camera
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// TimeoutError is returned if the camera does not respond within the deadline of the command
+type TimeoutError struct {
+	Request []byte        // message sent to the camera
+	Wait    time.Duration // deadline of the response
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("no response to %s within %v", hex.EncodeToString(e.Request), e.Wait)
+}
+
+// Timeout is true (like net.Error)
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+var errFrameTimeout = errors.New("frame timeout")
+
+const (
+	frameReaderBufferSize  = 256
+	frameReaderStopTimeout = 2 * time.Second // a Read blocked after closing the port is given up
+)
+
+// chunk of data read from the port (err = port closed or failed)
+type chunk struct {
+	data []byte
+	err  error
+}
+
+// frameReader reads the port in the background and splits the data into frames of the protocol,
+// therefore reading never blocks longer than the deadline of a command
+type frameReader struct {
+	split   bufio.SplitFunc
+	chunks  chan chunk
+	done    chan struct{}
+	exited  chan struct{} // reader goroutine returned
+	pending []byte        // received data which is not a complete frame yet
+	err     error         // read error of port (reader stopped)
+}
+
+// start reading r (the port must return from Read regularly, e.g. inter character timeout),
+// r is the opened port or connection (see readerOf), not a transport which may be opened again
+func newFrameReader(r io.Reader, split bufio.SplitFunc) *frameReader {
+	f := &frameReader{
+		split:  split,
+		chunks: make(chan chunk, 16),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	go f.run(r)
+	return f
+}
+
+func (f *frameReader) run(r io.Reader) {
+	defer close(f.exited)
+	defer close(f.chunks)
+	for {
+		buf := make([]byte, frameReaderBufferSize)
+		n, err := r.Read(buf)
+		if err == io.EOF {
+			// no data within inter character timeout
+			err = nil
+		}
+		if n == 0 && err == nil {
+			select {
+			case <-f.done:
+				return
+			default:
+				continue
+			}
+		}
+		select {
+		case f.chunks <- chunk{buf[:n], err}:
+		case <-f.done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// stop reading (the port has to be closed to stop a blocked Read)
+func (f *frameReader) close() {
+	select {
+	case <-f.done:
+	default:
+		close(f.done)
+	}
+}
+
+// wait until the reader returned after close and closing the port
+func (f *frameReader) wait() {
+	select {
+	case <-f.exited:
+	case <-time.After(frameReaderStopTimeout):
+		log.Printf("Frame reader not stopped within %v\n", frameReaderStopTimeout)
+	}
+}
+
+// discard returns and drops received data (e.g. responses to commands without awaited response)
+func (f *frameReader) discard() []byte {
+	data := f.pending
+	f.pending = nil
+	for {
+		select {
+		case c, ok := <-f.chunks:
+			if !ok {
+				return data
+			}
+			data = append(data, c.data...)
+			if c.err != nil {
+				f.err = c.err
+			}
+		default:
+			return data
+		}
+	}
+}
+
+// failed returns the read error of the port (nil = reading)
+func (f *frameReader) failed() error {
+	return f.err
+}
+
+// next returns the next frame received until deadline (errFrameTimeout) or the read error of the port
+func (f *frameReader) next(deadline time.Time) ([]byte, error) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	for {
+		advance, token, err := f.split(f.pending, false)
+		if err != nil {
+			f.pending = nil
+			return nil, err
+		}
+		if advance > 0 {
+			f.pending = f.pending[advance:]
+			if token != nil {
+				return append([]byte{}, token...), nil
+			}
+			continue
+		}
+		if f.err != nil {
+			return nil, f.err
+		}
+		select {
+		case c, ok := <-f.chunks:
+			if !ok {
+				f.err = io.ErrClosedPipe
+				continue
+			}
+			f.pending = append(f.pending, c.data...)
+			f.err = c.err
+		case <-timer.C:
+			return nil, errFrameTimeout
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package camera
+
+import (
+	"bytes"
+	"camcontrol/camera/pelcod"
+	"testing"
+	"time"
+)
+
+// reconnect stops the reader of the closed port before the reader of the new port starts
+func TestFrameReaderReconnect(t *testing.T) {
+	transport := NewMemoryTransport("reconnect", func(request []byte) []byte {
+		return request // echo
+	})
+	l, err := newTransportLink(transport, pelcod.ScanFrames, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	msg := pelcod.Encode(1, pelcod.QueryPan{}).Bytes()
+	for i := 0; i < 3; i++ {
+		frames, err := l.send(msg, response{timeout: 100 * time.Millisecond})
+		if err != nil || len(frames) != 1 || !bytes.Equal(frames[0], msg) {
+			t.Fatalf("send %d: %x, %v", i, frames, err)
+		}
+		old := l.reader
+		l.mu.Lock()
+		err = l.open()
+		l.mu.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-old.exited:
+		default:
+			t.Errorf("reader of closed port is running")
+		}
+		if l.reader == old {
+			t.Errorf("reader of closed port is used")
+		}
+	}
+}
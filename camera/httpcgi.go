@@ -0,0 +1,419 @@
+package camera
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Using HTTP CGI commands of network cameras (e.g. PTZOptics or Panasonic AW), the commands are
+// URL templates per vendor (see HTTPVendors and LoadHTTPVendors), authentication is basic or digest (see httpdigest.go)
+
+const (
+	httpTimeout      = 2 * time.Second
+	httpMaxZoomSpeed = 0x3f // zoom speed range (like Pelco-D speed)
+)
+
+// HTTPVendor describes the CGI commands of a camera vendor. The URL templates (path and query
+// relative to http://host) may contain placeholders, an empty template is an unsupported command:
+//
+//	{panspeed}, {tiltspeed}, {zoomspeed}: speed 1..MaxPanSpeed, MaxTiltSpeed, MaxZoomSpeed
+//	{pan}, {tilt}, {zoom}: Center + direction * speed (right, up, tele is positive), two digits
+//	{preset}: preset number + PresetOffset, two digits
+type HTTPVendor struct {
+	PanTilt      [3][3]string `json:"panTilt"` // by [tilt+1][pan+1]: down, stop, up x left, stop, right ([1][1] stops pan/tilt)
+	Zoom         [3]string    `json:"zoom"`    // by [zoom+1]: wide, stop, tele
+	PresetSelect string       `json:"presetSelect"`
+	PresetSave   string       `json:"presetSave"`
+	PowerOn      string       `json:"powerOn"`
+	PowerOff     string       `json:"powerOff"` // standby
+
+	MaxPanSpeed  byte `json:"maxPanSpeed"`
+	MaxTiltSpeed byte `json:"maxTiltSpeed"`
+	MaxZoomSpeed byte `json:"maxZoomSpeed"`
+	Center       byte `json:"center"`       // value of {pan}, {tilt}, {zoom} for stop
+	PresetOffset int  `json:"presetOffset"` // added to preset number 1..n of application
+	MaxPresets   int  `json:"maxPresets"`   // highest preset number of application
+}
+
+// HTTPVendors are the known vendors for NewHTTPCGI (built-in vendors, further vendors see LoadHTTPVendors)
+var HTTPVendors = map[string]HTTPVendor{
+	// PTZOptics and many other cameras with ptzctrl.cgi (pan speed 1..24, tilt speed 1..20, zoom speed 1..7)
+	"ptzoptics": {
+		PanTilt: [3][3]string{
+			{"/cgi-bin/ptzctrl.cgi?ptzcmd&leftdown&{panspeed}&{tiltspeed}", "/cgi-bin/ptzctrl.cgi?ptzcmd&down&{panspeed}&{tiltspeed}", "/cgi-bin/ptzctrl.cgi?ptzcmd&rightdown&{panspeed}&{tiltspeed}"},
+			{"/cgi-bin/ptzctrl.cgi?ptzcmd&left&{panspeed}&{tiltspeed}", "/cgi-bin/ptzctrl.cgi?ptzcmd&ptzstop&0&0", "/cgi-bin/ptzctrl.cgi?ptzcmd&right&{panspeed}&{tiltspeed}"},
+			{"/cgi-bin/ptzctrl.cgi?ptzcmd&leftup&{panspeed}&{tiltspeed}", "/cgi-bin/ptzctrl.cgi?ptzcmd&up&{panspeed}&{tiltspeed}", "/cgi-bin/ptzctrl.cgi?ptzcmd&rightup&{panspeed}&{tiltspeed}"},
+		},
+		Zoom:         [3]string{"/cgi-bin/ptzctrl.cgi?ptzcmd&zoomout&{zoomspeed}", "/cgi-bin/ptzctrl.cgi?ptzcmd&zoomstop&0", "/cgi-bin/ptzctrl.cgi?ptzcmd&zoomin&{zoomspeed}"},
+		PresetSelect: "/cgi-bin/ptzctrl.cgi?ptzcmd&poscall&{preset}",
+		PresetSave:   "/cgi-bin/ptzctrl.cgi?ptzcmd&posset&{preset}",
+		MaxPanSpeed:  24,
+		MaxTiltSpeed: 20,
+		MaxZoomSpeed: 7,
+		MaxPresets:   254,
+	},
+	// Panasonic AW series: #PTS<pan><tilt> and #Z<zoom> with 01..99 (50 = stop), presets 00..99
+	"panasonic": {
+		PanTilt:      panTiltAll("/cgi-bin/aw_ptz?cmd=%23PTS{pan}{tilt}&res=1"),
+		Zoom:         [3]string{"/cgi-bin/aw_ptz?cmd=%23Z{zoom}&res=1", "/cgi-bin/aw_ptz?cmd=%23Z{zoom}&res=1", "/cgi-bin/aw_ptz?cmd=%23Z{zoom}&res=1"},
+		PresetSelect: "/cgi-bin/aw_ptz?cmd=%23R{preset}&res=1",
+		PresetSave:   "/cgi-bin/aw_ptz?cmd=%23M{preset}&res=1",
+		PowerOn:      "/cgi-bin/aw_ptz?cmd=%23O1&res=1",
+		PowerOff:     "/cgi-bin/aw_ptz?cmd=%23O0&res=1",
+		MaxPanSpeed:  49,
+		MaxTiltSpeed: 49,
+		MaxZoomSpeed: 49,
+		Center:       50,
+		PresetOffset: -1,
+		MaxPresets:   100,
+	},
+}
+
+// LoadHTTPVendors adds the vendors of a JSON file to HTTPVendors (object of vendor name and HTTPVendor,
+// a built-in vendor of the same name is replaced), it is called before cameras are created, e.g.
+//
+//	{"myvendor": {"panTilt": [["", "/ptz?move=down", ""], ["/ptz?move=left", "/ptz?move=stop", "/ptz?move=right"], ["", "/ptz?move=up", ""]],
+//	              "zoom": ["/ptz?zoom=wide", "/ptz?zoom=stop", "/ptz?zoom=tele"], "presetSelect": "/ptz?goto={preset}",
+//	              "maxPanSpeed": 1, "maxTiltSpeed": 1, "maxZoomSpeed": 1, "maxPresets": 16}}
+func LoadHTTPVendors(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed reading HTTP vendors: %v", err)
+	}
+	var vendors map[string]HTTPVendor
+	if err := json.Unmarshal(data, &vendors); err != nil {
+		return fmt.Errorf("failed reading HTTP vendors %v: %v", path, err)
+	}
+	for name, v := range vendors {
+		if err := v.validate(); err != nil {
+			return fmt.Errorf("HTTP vendor '%v' of %v: %v", name, path, err)
+		}
+	}
+	for name, v := range vendors {
+		HTTPVendors[strings.ToLower(name)] = v
+	}
+	return nil
+}
+
+// speed ranges start at 1 (see scaleSpeed), presets are numbered 1..MaxPresets
+func (v HTTPVendor) validate() error {
+	if v.MaxPanSpeed < 1 || v.MaxTiltSpeed < 1 || v.MaxZoomSpeed < 1 {
+		return fmt.Errorf("invalid speed range %d, %d, %d (1..255)", v.MaxPanSpeed, v.MaxTiltSpeed, v.MaxZoomSpeed)
+	}
+	if v.MaxPresets < 0 || v.MaxPresets > 255 {
+		return fmt.Errorf("invalid max presets %d (0..255)", v.MaxPresets)
+	}
+	if v.PanTilt[1][1] == "" {
+		return fmt.Errorf("no pan/tilt stop command")
+	}
+	return nil
+}
+
+// same template for all pan/tilt directions (direction is given by placeholders)
+func panTiltAll(template string) (t [3][3]string) {
+	for i := range t {
+		for j := range t[i] {
+			t[i][j] = template
+		}
+	}
+	return
+}
+
+type httpCamera struct {
+	mu       sync.Mutex // requests of UI and position readout share the digest nonce count
+	vendor   HTTPVendor
+	name     string // vendor name
+	base     string // scheme and host, e.g. http://192.168.0.88
+	user     string
+	password string
+	digest   *digestAuth // challenge of camera, nil = basic authentication
+	http     *http.Client
+}
+
+func init() {
+	Register(Driver{
+		Name:        "http-cgi",
+		Description: "network camera with HTTP CGI commands of vendor (see HTTPVendors)",
+		Schema:      Schema{Host: true, Vendor: true, Auth: true},
+		New: func(o Options) (Camera, error) {
+			cam, err := NewHTTPCGI(o.Vendor, o.Host, o.User, o.Password)
+			if cam == nil {
+				return nil, err
+			}
+			return cam, err
+		},
+	})
+}
+
+// create camera object for HTTP CGI camera of vendor (host name or base URL, user may be empty)
+func NewHTTPCGI(vendor, host, user, password string) (*httpCamera, error) {
+	v, ok := HTTPVendors[strings.ToLower(vendor)]
+	if !ok {
+		return nil, fmt.Errorf("unknown HTTP camera vendor '%v'", vendor)
+	}
+	if host == "" {
+		return nil, fmt.Errorf("%v: no camera host given", vendor)
+	}
+	base := strings.TrimSuffix(host, "/")
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	log.Printf("HTTP use %v camera %v\n", vendor, base)
+	return &httpCamera{
+		vendor:   v,
+		name:     strings.ToLower(vendor),
+		base:     base,
+		user:     user,
+		password: password,
+		http:     &http.Client{Timeout: httpTimeout},
+	}, nil
+}
+
+func (c *httpCamera) Close() {
+	c.http.CloseIdleConnections()
+}
+
+func (c *httpCamera) Capabilities() Capabilities {
+	return Capabilities{
+		VariableSpeed: true,
+		Diagonal:      c.vendor.PanTilt[2][2] != "",
+		Power:         c.vendor.PowerOn != "",
+		MaxPresets:    c.vendor.MaxPresets,
+	}
+}
+
+func (c *httpCamera) Up(speed float64) error {
+	log.Printf("Cam up %.2f\n", speed)
+	return c.panTilt(0, 1, 0, speed)
+}
+
+func (c *httpCamera) Down(speed float64) error {
+	log.Printf("Cam down %.2f\n", speed)
+	return c.panTilt(0, -1, 0, speed)
+}
+
+func (c *httpCamera) Left(speed float64) error {
+	log.Printf("Cam left %.2f\n", speed)
+	return c.panTilt(-1, 0, speed, 0)
+}
+
+func (c *httpCamera) Right(speed float64) error {
+	log.Printf("Cam right %.2f\n", speed)
+	return c.panTilt(1, 0, speed, 0)
+}
+
+func (c *httpCamera) PtStop() error {
+	log.Println("Cam stop pt")
+	return c.panTilt(0, 0, 0, 0)
+}
+
+// pan/tilt and zoom are separate commands
+func (c *httpCamera) Move(pan, tilt, zoom float64) error {
+	log.Printf("Cam move %.2f %.2f %.2f\n", pan, tilt, zoom)
+	if err := c.panTilt(sign(pan), sign(tilt), math.Abs(pan), math.Abs(tilt)); err != nil {
+		return err
+	}
+	return c.zoom(sign(zoom), math.Abs(zoom))
+}
+
+func (c *httpCamera) ZoomIn(speed byte) error {
+	log.Println("Cam zoom in")
+	return c.zoom(1, float64(speed)/httpMaxZoomSpeed)
+}
+
+func (c *httpCamera) ZoomOut(speed byte) error {
+	log.Println("Cam zoom out")
+	return c.zoom(-1, float64(speed)/httpMaxZoomSpeed)
+}
+
+func (c *httpCamera) ZoomStop() error {
+	log.Println("Cam zoom stop")
+	return c.zoom(0, 0)
+}
+
+// focus commands are not part of the vendor templates
+func (c *httpCamera) FocusNear(speed byte) error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) FocusFar(speed byte) error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) FocusStop() error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) FocusAuto() error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) FocusManual() error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) FocusOnePush() error {
+	return ErrNotSupported
+}
+
+// image commands are not part of the vendor templates
+func (c *httpCamera) IrisOpen() error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) IrisClose() error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) GainUp() error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) GainDown() error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) Exposure(mode ExposureMode) error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) Backlight(on bool) error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) WhiteBalance(mode WhiteBalanceMode) error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) WhiteBalanceTrigger() error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) PresetSelect(preset byte) error {
+	log.Printf("Cam preset select %d\n", preset)
+	return c.preset(c.vendor.PresetSelect, preset)
+}
+
+func (c *httpCamera) PresetSave(preset byte) error {
+	log.Printf("Cam preset save %d\n", preset)
+	return c.preset(c.vendor.PresetSave, preset)
+}
+
+// the CGI commands of the vendors have no position query
+func (c *httpCamera) Position() (Position, error) {
+	return Position{}, ErrNotSupported
+}
+
+func (c *httpCamera) AbsoluteMove(pan, tilt, zoom float64) error {
+	return ErrNotSupported
+}
+
+func (c *httpCamera) Power(on bool) error {
+	log.Printf("Cam power %v\n", on)
+	template := c.vendor.PowerOff
+	if on {
+		template = c.vendor.PowerOn
+	}
+	if template == "" {
+		return ErrNotSupported
+	}
+	return c.get(template, strings.NewReplacer())
+}
+
+func (c *httpCamera) Reset() error {
+	return ErrNotSupported
+}
+
+// pan/tilt with direction -1, 0, 1 and normalized speed 0..1
+func (c *httpCamera) panTilt(pan, tilt int8, panSpeed, tiltSpeed float64) error {
+	ps, ts := scaleSpeed(panSpeed, 1, c.vendor.MaxPanSpeed), scaleSpeed(tiltSpeed, 1, c.vendor.MaxTiltSpeed)
+	return c.get(c.vendor.PanTilt[tilt+1][pan+1], strings.NewReplacer(
+		"{panspeed}", strconv.Itoa(int(ps)),
+		"{tiltspeed}", strconv.Itoa(int(ts)),
+		"{pan}", c.centered(pan, ps),
+		"{tilt}", c.centered(tilt, ts),
+	))
+}
+
+// zoom with direction -1, 0, 1 and normalized speed 0..1
+func (c *httpCamera) zoom(zoom int8, speed float64) error {
+	zs := scaleSpeed(speed, 1, c.vendor.MaxZoomSpeed)
+	return c.get(c.vendor.Zoom[zoom+1], strings.NewReplacer(
+		"{zoomspeed}", strconv.Itoa(int(zs)),
+		"{zoom}", c.centered(zoom, zs),
+	))
+}
+
+func (c *httpCamera) preset(template string, preset byte) error {
+	n := int(preset) + c.vendor.PresetOffset
+	if n < 0 {
+		return fmt.Errorf("%v: invalid preset %d", c.name, preset)
+	}
+	return c.get(template, strings.NewReplacer("{preset}", fmt.Sprintf("%02d", n)))
+}
+
+// value of {pan}, {tilt}, {zoom}: center + direction * speed
+func (c *httpCamera) centered(direction int8, speed byte) string {
+	return fmt.Sprintf("%02d", int(c.vendor.Center)+int(direction)*int(speed))
+}
+
+// send command (template with replaced placeholders) and check HTTP status
+func (c *httpCamera) get(template string, r *strings.Replacer) error {
+	if template == "" {
+		return fmt.Errorf("%v: command not supported by camera", c.name)
+	}
+	url := c.base + r.Replace(template)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp, err := c.request(url)
+	if err != nil {
+		return fmt.Errorf("%v: %v", c.name, err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	log.Printf("Request %v: %v %s\n", url, resp.Status, strings.TrimSpace(string(body)))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v: command failed: %v", c.name, resp.Status)
+	}
+	return nil
+}
+
+// GET with authentication, a digest challenge of the camera is answered and kept for further requests
+func (c *httpCamera) request(url string) (*http.Response, error) {
+	resp, err := c.do(url)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || c.user == "" {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if !strings.HasPrefix(strings.ToLower(challenge), "digest ") {
+		return resp, err
+	}
+	resp.Body.Close()
+	if c.digest, err = newDigestAuth(challenge); err != nil {
+		return nil, err
+	}
+	return c.do(url)
+}
+
+func (c *httpCamera) do(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.user != "" {
+		if c.digest != nil {
+			req.Header.Set("Authorization", c.digest.authorization(c.user, c.password, req.Method, req.URL.RequestURI()))
+		} else {
+			req.SetBasicAuth(c.user, c.password)
+		}
+	}
+	return c.http.Do(req)
+}
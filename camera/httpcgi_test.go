@@ -0,0 +1,150 @@
+package camera
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeVendors writes a vendors file and removes its vendors from HTTPVendors after the test
+func writeVendors(t *testing.T, data string, names ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vendors.json")
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		for _, name := range names {
+			delete(HTTPVendors, name)
+		}
+	})
+	return path
+}
+
+// vendor of file with digest authentication, concurrent requests use increasing nonce counts
+func TestHTTPVendorFile(t *testing.T) {
+	path := writeVendors(t, `{"Test-Vendor": {
+		"panTilt": [["", "", ""], ["", "/ptz?stop", ""], ["", "", ""]],
+		"presetSelect": "/ptz?goto={preset}",
+		"maxPanSpeed": 1, "maxTiltSpeed": 1, "maxZoomSpeed": 1, "maxPresets": 16}}`, "test-vendor")
+	if err := LoadHTTPVendors(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu     sync.Mutex
+		counts = map[string]bool{}
+		last   string
+	)
+	ncPattern := regexp.MustCompile(`nc=([0-9a-f]{8})`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := ncPattern.FindStringSubmatch(r.Header.Get("Authorization"))
+		if m == nil {
+			w.Header().Set("WWW-Authenticate", `Digest realm="cam", nonce="abc", qop="auth"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if counts[m[1]] || m[1] <= last {
+			t.Errorf("nonce count %v after %v", m[1], last)
+		}
+		counts[m[1]], last = true, m[1]
+		if r.URL.RequestURI() != "/ptz?goto=03" {
+			t.Errorf("request %v", r.URL.RequestURI())
+		}
+	}))
+	defer server.Close()
+
+	cam, err := NewHTTPCGI("test-vendor", server.URL, "admin", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cam.Close()
+	if n := cam.Capabilities().MaxPresets; n != 16 {
+		t.Errorf("max presets %d, want 16", n)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cam.PresetSelect(3); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(counts) != 8 {
+		t.Errorf("%d nonce counts, want 8", len(counts))
+	}
+}
+
+func TestHTTPVendorFileInvalid(t *testing.T) {
+	path := writeVendors(t, `{"slow": {"panTilt": [["", "", ""], ["", "/stop", ""], ["", "", ""]]}}`, "slow")
+	if err := LoadHTTPVendors(path); err == nil {
+		t.Errorf("vendor without speed range accepted")
+	}
+	if _, ok := HTTPVendors["slow"]; ok {
+		t.Errorf("invalid vendor added")
+	}
+}
+
+// request URLs of the built-in vendors (Panasonic: speed around center 50, presets 0-based)
+func TestHTTPVendors(t *testing.T) {
+	tests := []struct {
+		vendor string
+		speed  float64  // of Up
+		want   []string // Up, PtStop, ZoomIn, ZoomOut, ZoomStop, PresetSelect(1), PresetSave(12)
+	}{
+		{"ptzoptics", 1, []string{
+			"/cgi-bin/ptzctrl.cgi?ptzcmd&up&1&20",
+			"/cgi-bin/ptzctrl.cgi?ptzcmd&ptzstop&0&0",
+			"/cgi-bin/ptzctrl.cgi?ptzcmd&zoomin&7",
+			"/cgi-bin/ptzctrl.cgi?ptzcmd&zoomout&7",
+			"/cgi-bin/ptzctrl.cgi?ptzcmd&zoomstop&0",
+			"/cgi-bin/ptzctrl.cgi?ptzcmd&poscall&01",
+			"/cgi-bin/ptzctrl.cgi?ptzcmd&posset&12",
+		}},
+		{"panasonic", 0.5, []string{ // tilt speed 25 of 1..49
+			"/cgi-bin/aw_ptz?cmd=%23PTS5075&res=1",
+			"/cgi-bin/aw_ptz?cmd=%23PTS5050&res=1",
+			"/cgi-bin/aw_ptz?cmd=%23Z99&res=1",
+			"/cgi-bin/aw_ptz?cmd=%23Z01&res=1",
+			"/cgi-bin/aw_ptz?cmd=%23Z50&res=1",
+			"/cgi-bin/aw_ptz?cmd=%23R00&res=1",
+			"/cgi-bin/aw_ptz?cmd=%23M11&res=1",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vendor, func(t *testing.T) {
+			var requests []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.URL.RequestURI())
+			}))
+			defer server.Close()
+			cam, err := NewHTTPCGI(tt.vendor, server.URL, "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cam.Close()
+			for _, err := range []error{
+				cam.Up(tt.speed), cam.PtStop(), cam.ZoomIn(0x3f), cam.ZoomOut(0x3f), cam.ZoomStop(),
+				cam.PresetSelect(1), cam.PresetSave(12),
+			} {
+				if err != nil {
+					t.Error(err)
+				}
+			}
+			if !reflect.DeepEqual(requests, tt.want) {
+				t.Errorf("requests\n%v\nwant\n%v", strings.Join(requests, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
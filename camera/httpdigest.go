@@ -0,0 +1,101 @@
+package camera
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// HTTP digest access authentication (RFC 7616) for cameras which reject basic authentication
+
+type digestAuth struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string // MD5 or SHA-256
+	qop       string // "auth" or empty (RFC 2069)
+	nc        int    // nonce count of last request (camera mutex is locked)
+}
+
+// parse challenge of WWW-Authenticate header, e.g. Digest realm="cam", nonce="abc", qop="auth"
+func newDigestAuth(challenge string) (*digestAuth, error) {
+	params := map[string]string{}
+	for _, p := range splitParams(challenge[len("Digest "):]) {
+		if i := strings.Index(p, "="); i > 0 {
+			params[strings.ToLower(strings.TrimSpace(p[:i]))] = strings.Trim(strings.TrimSpace(p[i+1:]), `"`)
+		}
+	}
+	d := digestAuth{
+		realm:     params["realm"],
+		nonce:     params["nonce"],
+		opaque:    params["opaque"],
+		algorithm: params["algorithm"],
+	}
+	if d.nonce == "" {
+		return nil, fmt.Errorf("invalid digest challenge '%v'", challenge)
+	}
+	switch strings.ToUpper(d.algorithm) {
+	case "", "MD5", "SHA-256":
+	default:
+		return nil, fmt.Errorf("unsupported digest algorithm '%v'", d.algorithm)
+	}
+	for _, q := range strings.Split(params["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			d.qop = "auth"
+		}
+	}
+	return &d, nil
+}
+
+// split comma separated parameters (commas in quoted values are kept)
+func splitParams(s string) (params []string) {
+	quoted, start := false, 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			params = append(params, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(params, s[start:])
+}
+
+// value of Authorization header for request
+func (d *digestAuth) authorization(user, password, method, uri string) string {
+	ha1 := d.hash(user + ":" + d.realm + ":" + password)
+	ha2 := d.hash(method + ":" + uri)
+	auth := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s"`, user, d.realm, d.nonce, uri)
+	if d.qop != "" {
+		d.nc++
+		b := make([]byte, 8)
+		_, _ = rand.Read(b)
+		cnonce := hex.EncodeToString(b)
+		nc := fmt.Sprintf("%08x", d.nc)
+		auth += fmt.Sprintf(`, qop=%s, nc=%s, cnonce="%s", response="%s"`,
+			d.qop, nc, cnonce, d.hash(ha1+":"+d.nonce+":"+nc+":"+cnonce+":"+d.qop+":"+ha2))
+	} else {
+		auth += fmt.Sprintf(`, response="%s"`, d.hash(ha1+":"+d.nonce+":"+ha2))
+	}
+	if d.opaque != "" {
+		auth += fmt.Sprintf(`, opaque="%s"`, d.opaque)
+	}
+	if d.algorithm != "" {
+		auth += ", algorithm=" + d.algorithm
+	}
+	return auth
+}
+
+func (d *digestAuth) hash(s string) string {
+	var h hash.Hash = md5.New()
+	if strings.EqualFold(d.algorithm, "SHA-256") {
+		h = sha256.New()
+	}
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,47 @@
+// Package position converts between the normalized positions of camera.Position and the units of the
+// protocols, it is shared by the camera drivers and the simulator so both sides use the same scale.
+package position
+
+import "math"
+
+// position limits of common VISCA cameras (e.g. PTZOptics, Sony BRC: pan ±170°, tilt -30..90°), used for normalization
+const (
+	ViscaPanLimit      = 0x0990
+	ViscaTiltDownLimit = -0x0190
+	ViscaTiltUpLimit   = 0x04b0
+	ViscaZoomTeleLimit = 0x4000
+)
+
+// Normalize raw value of range min..0..max to -1..0..1 (range may be asymmetric, e.g. tilt)
+func Normalize(v, min, max float64) float64 {
+	if v >= 0 {
+		return math.Min(1, v/max)
+	}
+	return math.Max(-1, -v/min)
+}
+
+// Denormalize returns the raw value of range min..0..max of normalized value -1..0..1 (inverse of Normalize)
+func Denormalize(v, min, max float64) float64 {
+	v = math.Max(-1, math.Min(1, v))
+	if v >= 0 {
+		return v * max
+	}
+	return -v * min
+}
+
+// Hundredths converts degrees -180..180 to hundredths of degree 0..35999 (Pelco positions)
+func Hundredths(degrees float64) uint16 {
+	v := math.Round(degrees * 100)
+	if v < 0 {
+		v += 36000
+	}
+	return uint16(math.Mod(v, 36000))
+}
+
+// SignedDegrees converts hundredths of degree 0..35999 to degrees -180..180 (inverse of Hundredths)
+func SignedDegrees(v float64) float64 {
+	if v > 18000 {
+		v -= 36000
+	}
+	return v / 100
+}
@@ -0,0 +1,43 @@
+package position
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw, min, max, v float64
+	}{
+		{0, ViscaTiltDownLimit, ViscaTiltUpLimit, 0},
+		{ViscaTiltUpLimit, ViscaTiltDownLimit, ViscaTiltUpLimit, 1},
+		{ViscaTiltDownLimit / 2, ViscaTiltDownLimit, ViscaTiltUpLimit, -0.5},
+		{-90, -90, 90, -1},
+	}
+	for _, tt := range tests {
+		if v := Normalize(tt.raw, tt.min, tt.max); v != tt.v {
+			t.Errorf("Normalize(%v, %v, %v) = %v, want %v", tt.raw, tt.min, tt.max, v, tt.v)
+		}
+		if raw := Denormalize(tt.v, tt.min, tt.max); raw != tt.raw {
+			t.Errorf("Denormalize(%v, %v, %v) = %v, want %v", tt.v, tt.min, tt.max, raw, tt.raw)
+		}
+	}
+}
+
+func TestHundredths(t *testing.T) {
+	tests := []struct {
+		degrees float64
+		v       uint16
+	}{
+		{0, 0},
+		{90, 9000},
+		{-90, 27000},
+		{-0.01, 35999},
+		{180, 18000},
+	}
+	for _, tt := range tests {
+		if v := Hundredths(tt.degrees); v != tt.v {
+			t.Errorf("Hundredths(%v) = %v, want %v", tt.degrees, v, tt.v)
+		}
+		if d := SignedDegrees(float64(tt.v)); d != tt.degrees {
+			t.Errorf("SignedDegrees(%v) = %v, want %v", tt.v, d, tt.degrees)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package camera
+
+import (
+	"io"
+	"sync"
+)
+
+// memory transport connects a driver to a camera in the same process, e.g. simulator or tests
+
+type memoryTransport struct {
+	name   string
+	handle func(request []byte) []byte
+
+	mu   sync.Mutex  // connection is replaced on open
+	conn *memoryConn // nil = not opened
+}
+
+// memoryConn is an opened memory transport
+type memoryConn struct {
+	data    chan []byte   // responses of handle
+	closed  chan struct{} // closed by Close of the transport
+	pending []byte        // rest of response (read by frame reader only)
+}
+
+// NewMemoryTransport returns a transport passing every written message to handle,
+// the returned bytes are the response read by the driver (nil = no response)
+func NewMemoryTransport(name string, handle func(request []byte) []byte) Transport {
+	return &memoryTransport{name: name, handle: handle}
+}
+
+func (t *memoryTransport) Open() error {
+	t.Close()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.conn = &memoryConn{data: make(chan []byte, 16), closed: make(chan struct{})}
+	return nil
+}
+
+func (t *memoryTransport) opened() *memoryConn {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.conn
+}
+
+func (t *memoryTransport) Read(p []byte) (int, error) {
+	conn := t.opened()
+	if conn == nil {
+		return 0, io.ErrClosedPipe
+	}
+	return conn.Read(p)
+}
+
+func (t *memoryTransport) reader() io.Reader {
+	if conn := t.opened(); conn != nil {
+		return conn
+	}
+	return nil
+}
+
+func (c *memoryConn) Read(p []byte) (int, error) {
+	if len(c.pending) == 0 {
+		select {
+		case c.pending = <-c.data:
+		case <-c.closed:
+			return 0, io.ErrClosedPipe
+		}
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (t *memoryTransport) Write(p []byte) (int, error) {
+	conn := t.opened()
+	if conn == nil {
+		return 0, io.ErrClosedPipe
+	}
+	if r := t.handle(append([]byte{}, p...)); len(r) > 0 {
+		select {
+		case conn.data <- r:
+		case <-conn.closed:
+			return 0, io.ErrClosedPipe
+		}
+	}
+	return len(p), nil
+}
+
+func (t *memoryTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn != nil {
+		close(t.conn.closed)
+		t.conn = nil
+	}
+	return nil
+}
+
+func (t *memoryTransport) Address() string {
+	return "memory://" + t.name
+}
+
+func (t *memoryTransport) String() string {
+	return t.Address()
+}
@@ -0,0 +1,108 @@
+package camera
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// network transport of the camera drivers (TCP or UDP socket), e.g. camera behind a serial server or VISCA on TCP
+
+const netDialTimeout = 2 * time.Second
+
+var errConnectionClosed = errors.New("connection closed by camera")
+
+type netTransport struct {
+	network string // "tcp" or "udp"
+	addr    string // host:port of camera
+
+	mu   sync.Mutex // connection is read by frame reader
+	conn net.Conn   // nil = closed
+}
+
+// NewTCPTransport returns the transport of a TCP connection to host:port
+func NewTCPTransport(addr string) Transport {
+	return &netTransport{network: "tcp", addr: addr}
+}
+
+// NewUDPTransport returns the transport of UDP datagrams to host:port (the protocol frames every datagram)
+func NewUDPTransport(addr string) Transport {
+	return &netTransport{network: "udp", addr: addr}
+}
+
+func (t *netTransport) Open() error {
+	t.Close()
+	conn, err := net.DialTimeout(t.network, t.addr, netDialTimeout)
+	if err != nil {
+		return err
+	}
+	log.Printf("%v connected to %v\n", t.network, t.addr)
+	t.mu.Lock()
+	t.conn = conn
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *netTransport) Read(p []byte) (int, error) {
+	conn := t.opened()
+	if conn == nil {
+		return 0, io.ErrClosedPipe
+	}
+	return connReader{conn}.Read(p)
+}
+
+func (t *netTransport) reader() io.Reader {
+	if conn := t.opened(); conn != nil {
+		return connReader{conn}
+	}
+	return nil
+}
+
+// connReader reads a connection until data or close (end of stream is an error, io.EOF would mean no data)
+type connReader struct {
+	conn net.Conn
+}
+
+func (r connReader) Read(p []byte) (int, error) {
+	n, err := r.conn.Read(p)
+	if err == io.EOF {
+		err = errConnectionClosed
+	}
+	return n, err
+}
+
+func (t *netTransport) Write(p []byte) (int, error) {
+	conn := t.opened()
+	if conn == nil {
+		return 0, io.ErrClosedPipe
+	}
+	return conn.Write(p)
+}
+
+func (t *netTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
+
+func (t *netTransport) opened() net.Conn {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.conn
+}
+
+func (t *netTransport) Address() string {
+	return t.network + "://" + t.addr
+}
+
+func (t *netTransport) String() string {
+	return t.Address()
+}
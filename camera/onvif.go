@@ -0,0 +1,313 @@
+package camera
+
+import (
+	"camcontrol/camera/onvif"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// Using ONVIF PTZ service (see package onvif) for network cameras,
+// presets are named by their number (tokens are assigned by the camera)
+
+const (
+	onvifMinSpeed     = 0.05 // slowest velocity (velocity 0 stops movement)
+	onvifMaxZoomSpeed = 0x3f // zoom speed range (like Pelco-D speed)
+)
+
+type onvifCamera struct {
+	mu        sync.Mutex // requests of UI and position readout
+	client    *onvif.Client
+	connected bool
+	presets   map[byte]string // preset number -> token
+}
+
+func init() {
+	Register(Driver{
+		Name:        "onvif",
+		Description: "ONVIF network camera (PTZ service)",
+		Schema:      Schema{Host: true, Auth: true},
+		New: func(o Options) (Camera, error) {
+			return NewOnvif(o.Host, o.User, o.Password)
+		},
+	})
+}
+
+// create camera object for ONVIF camera (host name or device service URL)
+func NewOnvif(host, user, password string) (*onvifCamera, error) {
+	c := onvifCamera{
+		client:  onvif.New(host, user, password),
+		presets: map[byte]string{},
+	}
+	return &c, c.connect()
+}
+
+func (c *onvifCamera) connect() error {
+	if err := c.client.Connect(); err != nil {
+		c.connected = false
+		return err
+	}
+	log.Printf("ONVIF use camera %v profile %v", c.client.DeviceURL, c.client.ProfileToken)
+	c.connected = true
+	return nil
+}
+
+// ensure connection before request (mu is locked) (e.g. camera was not available on startup)
+func (c *onvifCamera) check() error {
+	if c.connected {
+		return nil
+	}
+	if err := c.connect(); err != nil {
+		return fmt.Errorf("failed connecting camera: %v", err)
+	}
+	return nil
+}
+
+func (c *onvifCamera) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connected = false
+}
+
+// focus and iris are part of the imaging service (not used), the number of presets is reported by the PTZ node
+func (c *onvifCamera) Capabilities() Capabilities {
+	return Capabilities{VariableSpeed: true, Diagonal: true, AbsoluteMove: true, PositionQuery: true, Reset: true}
+}
+
+func (c *onvifCamera) Up(speed float64) error {
+	log.Printf("Cam up %.2f\n", speed)
+	return c.move(onvif.Vector{Tilt: velocity(speed)})
+}
+
+func (c *onvifCamera) Down(speed float64) error {
+	log.Printf("Cam down %.2f\n", speed)
+	return c.move(onvif.Vector{Tilt: -velocity(speed)})
+}
+
+func (c *onvifCamera) Left(speed float64) error {
+	log.Printf("Cam left %.2f\n", speed)
+	return c.move(onvif.Vector{Pan: -velocity(speed)})
+}
+
+func (c *onvifCamera) Right(speed float64) error {
+	log.Printf("Cam right %.2f\n", speed)
+	return c.move(onvif.Vector{Pan: velocity(speed)})
+}
+
+func (c *onvifCamera) PtStop() error {
+	log.Println("Cam stop pt")
+	return c.stop(true, false)
+}
+
+func (c *onvifCamera) Move(pan, tilt, zoom float64) error {
+	log.Printf("Cam move %.2f %.2f %.2f\n", pan, tilt, zoom)
+	if pan == 0 && tilt == 0 && zoom == 0 {
+		return c.stop(true, true)
+	}
+	return c.move(onvif.Vector{Pan: clamp(pan), Tilt: clamp(tilt), Zoom: clamp(zoom)})
+}
+
+func (c *onvifCamera) ZoomIn(speed byte) error {
+	log.Println("Cam zoom in")
+	return c.move(onvif.Vector{Zoom: velocity(float64(speed) / onvifMaxZoomSpeed)})
+}
+
+func (c *onvifCamera) ZoomOut(speed byte) error {
+	log.Println("Cam zoom out")
+	return c.move(onvif.Vector{Zoom: -velocity(float64(speed) / onvifMaxZoomSpeed)})
+}
+
+func (c *onvifCamera) ZoomStop() error {
+	log.Println("Cam zoom stop")
+	return c.stop(false, true)
+}
+
+// focus is part of the imaging service (not used)
+func (c *onvifCamera) FocusNear(speed byte) error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) FocusFar(speed byte) error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) FocusStop() error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) FocusAuto() error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) FocusManual() error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) FocusOnePush() error {
+	return ErrNotSupported
+}
+
+// exposure and white balance are part of the imaging service (not used)
+func (c *onvifCamera) IrisOpen() error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) IrisClose() error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) GainUp() error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) GainDown() error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) Exposure(mode ExposureMode) error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) Backlight(on bool) error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) WhiteBalance(mode WhiteBalanceMode) error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) WhiteBalanceTrigger() error {
+	return ErrNotSupported
+}
+
+// ONVIF has no standby
+func (c *onvifCamera) Power(on bool) error {
+	return ErrNotSupported
+}
+
+func (c *onvifCamera) Reset() error {
+	log.Println("Cam reboot")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(); err != nil {
+		return err
+	}
+	if err := c.client.SystemReboot(); err != nil {
+		return err
+	}
+	// camera is not available until reboot is finished
+	c.connected = false
+	return nil
+}
+
+func (c *onvifCamera) PresetSelect(preset byte) error {
+	log.Printf("Cam preset select %d\n", preset)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(); err != nil {
+		return err
+	}
+	token, ok := c.presets[preset]
+	if !ok {
+		if err := c.readPresets(); err != nil {
+			return err
+		}
+		if token, ok = c.presets[preset]; !ok {
+			return fmt.Errorf("onvif: preset %d not stored", preset)
+		}
+	}
+	return c.client.GotoPreset(token)
+}
+
+func (c *onvifCamera) PresetSave(preset byte) error {
+	log.Printf("Cam preset save %d\n", preset)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(); err != nil {
+		return err
+	}
+	if _, ok := c.presets[preset]; !ok {
+		if err := c.readPresets(); err != nil {
+			return err
+		}
+	}
+	token, err := c.client.SetPreset(strconv.Itoa(int(preset)), c.presets[preset])
+	if err != nil {
+		return err
+	}
+	c.presets[preset] = token
+	return nil
+}
+
+// position of generic space (already normalized)
+func (c *onvifCamera) Position() (p Position, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(); err != nil {
+		return p, err
+	}
+	s, err := c.client.GetStatus()
+	if err != nil {
+		return p, err
+	}
+	p.Pan, p.Tilt, p.Zoom = s.Position.Pan, s.Position.Tilt, s.Position.Zoom
+	p.RawPan, p.RawTilt, p.RawZoom = p.Pan, p.Tilt, p.Zoom
+	return p, nil
+}
+
+func (c *onvifCamera) AbsoluteMove(pan, tilt, zoom float64) error {
+	log.Printf("Cam absolute move %.2f %.2f %.2f\n", pan, tilt, zoom)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(); err != nil {
+		return err
+	}
+	return c.client.AbsoluteMove(onvif.Vector{Pan: clamp(pan), Tilt: clamp(tilt), Zoom: math.Max(0, math.Min(1, zoom))})
+}
+
+// read preset tokens of the camera (preset name is the number, otherwise the token), mu is locked
+func (c *onvifCamera) readPresets() error {
+	presets, err := c.client.GetPresets()
+	if err != nil {
+		return err
+	}
+	for _, p := range presets {
+		if n, err := strconv.Atoi(p.Name); err == nil && n >= 0 && n <= 255 {
+			c.presets[byte(n)] = p.Token
+		} else if n, err := strconv.Atoi(p.Token); err == nil && n >= 0 && n <= 255 {
+			if _, ok := c.presets[byte(n)]; !ok {
+				c.presets[byte(n)] = p.Token
+			}
+		}
+	}
+	return nil
+}
+
+func (c *onvifCamera) move(v onvif.Vector) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(); err != nil {
+		return err
+	}
+	return c.client.ContinuousMove(v)
+}
+
+func (c *onvifCamera) stop(panTilt, zoom bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(); err != nil {
+		return err
+	}
+	return c.client.Stop(panTilt, zoom)
+}
+
+// normalized speed 0..1 to velocity onvifMinSpeed..1
+func velocity(speed float64) float64 {
+	return onvifMinSpeed + math.Max(0, math.Min(1, speed))*(1-onvifMinSpeed)
+}
+
+func clamp(v float64) float64 {
+	return math.Max(-1, math.Min(1, v))
+}
@@ -0,0 +1,195 @@
+// Package onvif is a minimal ONVIF client for the PTZ service (SOAP 1.2 with WS-Security UsernameToken digest).
+//
+// Connect reads the service addresses (GetCapabilities), the first media profile (GetProfiles)
+// and the clock of the device (GetSystemDateAndTime) to create valid security tokens.
+package onvif
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	nsDevice = "http://www.onvif.org/ver10/device/wsdl"
+	nsMedia  = "http://www.onvif.org/ver10/media/wsdl"
+	nsPTZ    = "http://www.onvif.org/ver20/ptz/wsdl"
+	nsSchema = "http://www.onvif.org/ver10/schema"
+
+	nsWsse         = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd"
+	nsWsu          = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd"
+	passwordDigest = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest"
+	base64Binary   = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0#Base64Binary"
+
+	timeout = 5 * time.Second
+)
+
+// Client of an ONVIF device
+type Client struct {
+	DeviceURL    string // device service, e.g. http://192.168.0.88/onvif/device_service
+	User         string
+	Password     string
+	ProfileToken string // media profile used for PTZ, empty = first profile of device
+
+	mediaURL   string
+	ptzURL     string
+	timeOffset time.Duration // clock of device - local clock
+	http       *http.Client
+}
+
+// New creates a client, host is the device service URL or the host name (default service path is used)
+func New(host, user, password string) *Client {
+	url := host
+	if !strings.Contains(host, "://") {
+		url = "http://" + host + "/onvif/device_service"
+	}
+	return &Client{
+		DeviceURL: url,
+		User:      user,
+		Password:  password,
+		http:      &http.Client{Timeout: timeout},
+	}
+}
+
+// Connect reads clock, service addresses and media profile of the device
+func (c *Client) Connect() error {
+	if err := c.syncTime(); err != nil {
+		return err
+	}
+	var caps struct {
+		Media string `xml:"Body>GetCapabilitiesResponse>Capabilities>Media>XAddr"`
+		PTZ   string `xml:"Body>GetCapabilitiesResponse>Capabilities>PTZ>XAddr"`
+	}
+	err := c.call(c.DeviceURL, nsDevice+"/GetCapabilities",
+		`<GetCapabilities xmlns="`+nsDevice+`"><Category>All</Category></GetCapabilities>`, &caps)
+	if err != nil {
+		return err
+	}
+	if caps.PTZ == "" {
+		return fmt.Errorf("onvif: device %v has no PTZ service", c.DeviceURL)
+	}
+	c.mediaURL, c.ptzURL = caps.Media, caps.PTZ
+	if c.ProfileToken != "" {
+		return nil
+	}
+	var profiles struct {
+		Profiles []struct {
+			Token string `xml:"token,attr"`
+		} `xml:"Body>GetProfilesResponse>Profiles"`
+	}
+	err = c.call(c.mediaURL, nsMedia+"/GetProfiles", `<GetProfiles xmlns="`+nsMedia+`"/>`, &profiles)
+	if err != nil {
+		return err
+	}
+	if len(profiles.Profiles) == 0 {
+		return fmt.Errorf("onvif: device %v has no media profile", c.DeviceURL)
+	}
+	c.ProfileToken = profiles.Profiles[0].Token
+	return nil
+}
+
+// SystemReboot restarts the device
+func (c *Client) SystemReboot() error {
+	return c.call(c.DeviceURL, nsDevice+"/SystemReboot", `<SystemReboot xmlns="`+nsDevice+`"/>`, nil)
+}
+
+// read device clock (unauthenticated) to calculate "created" of the security token
+func (c *Client) syncTime() error {
+	var t struct {
+		Date struct {
+			Year, Month, Day int
+		} `xml:"Body>GetSystemDateAndTimeResponse>SystemDateAndTime>UTCDateTime>Date"`
+		Time struct {
+			Hour, Minute, Second int
+		} `xml:"Body>GetSystemDateAndTimeResponse>SystemDateAndTime>UTCDateTime>Time"`
+	}
+	err := c.post(c.DeviceURL, nsDevice+"/GetSystemDateAndTime", envelope("", `<GetSystemDateAndTime xmlns="`+nsDevice+`"/>`), &t)
+	if err != nil {
+		return err
+	}
+	if t.Date.Year == 0 {
+		c.timeOffset = 0
+		return nil
+	}
+	device := time.Date(t.Date.Year, time.Month(t.Date.Month), t.Date.Day, t.Time.Hour, t.Time.Minute, t.Time.Second, 0, time.UTC)
+	c.timeOffset = time.Until(device)
+	return nil
+}
+
+// send authenticated SOAP request and decode the response envelope into resp
+func (c *Client) call(url, action, body string, resp interface{}) error {
+	header := ""
+	if c.User != "" {
+		header = c.security()
+	}
+	return c.post(url, action, envelope(header, body), resp)
+}
+
+func (c *Client) post(url, action, request string, resp interface{}) error {
+	r, err := c.http.Post(url, `application/soap+xml; charset=utf-8; action="`+action+`"`, strings.NewReader(request))
+	if err != nil {
+		return fmt.Errorf("onvif: %v", err)
+	}
+	defer r.Body.Close()
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("onvif: read response of %v failed: %v", action, err)
+	}
+	var fault struct {
+		Code   string `xml:"Body>Fault>Code>Subcode>Value"`
+		Reason string `xml:"Body>Fault>Reason>Text"`
+	}
+	if xml.Unmarshal(data, &fault) == nil && (fault.Reason != "" || fault.Code != "") {
+		return fmt.Errorf("onvif: %v failed: %v (%v)", action, fault.Reason, fault.Code)
+	}
+	if r.StatusCode != http.StatusOK {
+		return fmt.Errorf("onvif: %v failed: %v", action, r.Status)
+	}
+	if resp == nil {
+		return nil
+	}
+	if err = xml.Unmarshal(data, resp); err != nil {
+		return fmt.Errorf("onvif: invalid response of %v: %v", action, err)
+	}
+	return nil
+}
+
+func envelope(header, body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">` +
+		`<s:Header>` + header + `</s:Header>` +
+		`<s:Body>` + body + `</s:Body>` +
+		`</s:Envelope>`
+}
+
+// WS-Security UsernameToken: digest = base64(sha1(nonce + created + password))
+func (c *Client) security() string {
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+	created := time.Now().Add(c.timeOffset).UTC().Format("2006-01-02T15:04:05.000Z")
+	h := sha1.New()
+	h.Write(nonce)
+	h.Write([]byte(created))
+	h.Write([]byte(c.Password))
+	return `<wsse:Security s:mustUnderstand="1" xmlns:wsse="` + nsWsse + `" xmlns:wsu="` + nsWsu + `">` +
+		`<wsse:UsernameToken>` +
+		`<wsse:Username>` + escape(c.User) + `</wsse:Username>` +
+		`<wsse:Password Type="` + passwordDigest + `">` + base64.StdEncoding.EncodeToString(h.Sum(nil)) + `</wsse:Password>` +
+		`<wsse:Nonce EncodingType="` + base64Binary + `">` + base64.StdEncoding.EncodeToString(nonce) + `</wsse:Nonce>` +
+		`<wsu:Created>` + created + `</wsu:Created>` +
+		`</wsse:UsernameToken>` +
+		`</wsse:Security>`
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package onvif
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+// request of the client with UsernameToken and the parameters used by the test
+type request struct {
+	Username string `xml:"Header>Security>UsernameToken>Username"`
+	Password struct {
+		Type   string `xml:"Type,attr"`
+		Digest string `xml:",chardata"`
+	} `xml:"Header>Security>UsernameToken>Password"`
+	Nonce        string `xml:"Header>Security>UsernameToken>Nonce"`
+	Created      string `xml:"Header>Security>UsernameToken>Created"`
+	ProfileToken string `xml:"Body>ContinuousMove>ProfileToken"`
+	Velocity     struct {
+		PanTilt struct {
+			X string `xml:"x,attr"`
+			Y string `xml:"y,attr"`
+		}
+		Zoom struct {
+			X string `xml:"x,attr"`
+		}
+	} `xml:"Body>ContinuousMove>Velocity"`
+}
+
+// fakeDevice answers the operations with the recorded responses of testdata/<operation>.xml
+// (service address of operation is checked, {{url}} is replaced by the URL of the server)
+func fakeDevice(t *testing.T, user, password string, requests map[string]request) *httptest.Server {
+	t.Helper()
+	services := map[string]string{
+		"GetSystemDateAndTime": "/onvif/device_service",
+		"GetCapabilities":      "/onvif/device_service",
+		"GetProfiles":          "/onvif/Media",
+		"ContinuousMove":       "/onvif/PTZ",
+		"GetStatus":            "/onvif/PTZ",
+		"GetPresets":           "/onvif/PTZ",
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			t.Errorf("invalid content type %v", r.Header.Get("Content-Type"))
+		}
+		operation := path.Base(params["action"])
+		if services[operation] != r.URL.Path {
+			t.Errorf("%v sent to %v", operation, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		var req request
+		if err := xml.Unmarshal(data, &req); err != nil {
+			t.Errorf("%v: invalid request: %v", operation, err)
+		}
+		if operation != "GetSystemDateAndTime" {
+			checkDigest(t, operation, req, user, password)
+		}
+		requests[operation] = req
+		response, err := ioutil.ReadFile("testdata/" + operation + ".xml")
+		if err != nil {
+			t.Error(err)
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+		_, _ = w.Write([]byte(strings.ReplaceAll(string(response), "{{url}}", server.URL)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// UsernameToken digest = base64(sha1(nonce + created + password)), created in the clock of the device
+func checkDigest(t *testing.T, operation string, req request, user, password string) {
+	t.Helper()
+	if req.Username != user || req.Password.Type != passwordDigest {
+		t.Errorf("%v: user %q password type %q", operation, req.Username, req.Password.Type)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(req.Nonce)
+	if err != nil || len(nonce) != 16 {
+		t.Errorf("%v: invalid nonce %q", operation, req.Nonce)
+	}
+	h := sha1.New()
+	h.Write(nonce)
+	h.Write([]byte(req.Created))
+	h.Write([]byte(password))
+	if digest := base64.StdEncoding.EncodeToString(h.Sum(nil)); req.Password.Digest != digest {
+		t.Errorf("%v: digest %v, want %v", operation, req.Password.Digest, digest)
+	}
+	created, err := time.Parse("2006-01-02T15:04:05.000Z", req.Created)
+	device := time.Date(2024, 5, 14, 9, 30, 0, 0, time.UTC) // testdata/GetSystemDateAndTime.xml
+	if err != nil || created.Sub(device) < 0 || created.Sub(device) > 5*time.Second {
+		t.Errorf("%v: created %v not in clock of device %v", operation, req.Created, device)
+	}
+}
+
+func TestClient(t *testing.T) {
+	requests := map[string]request{}
+	server := fakeDevice(t, "admin", "pass&word", requests)
+	c := New(server.URL+"/onvif/device_service", "admin", "pass&word")
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if c.ProfileToken != "Profile_1" {
+		t.Errorf("profile %q, want Profile_1", c.ProfileToken)
+	}
+
+	if err := c.ContinuousMove(Vector{Pan: -0.5, Tilt: 0.25, Zoom: 1}); err != nil {
+		t.Fatal(err)
+	}
+	move := requests["ContinuousMove"]
+	if move.ProfileToken != "Profile_1" || move.Velocity.PanTilt.X != "-0.5000" || move.Velocity.PanTilt.Y != "0.2500" || move.Velocity.Zoom.X != "1.0000" {
+		t.Errorf("ContinuousMove request %+v", move)
+	}
+
+	s, err := c.GetStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Status{Position: Vector{Pan: -0.25, Tilt: 0.5, Zoom: 0.125}, PanTiltMoving: true}); s != want {
+		t.Errorf("GetStatus() = %+v, want %+v", s, want)
+	}
+
+	presets, err := c.GetPresets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presets) != 2 || presets[0] != (Preset{Token: "1", Name: "1"}) || presets[1] != (Preset{Token: "2", Name: "stage"}) {
+		t.Errorf("GetPresets() = %+v", presets)
+	}
+}
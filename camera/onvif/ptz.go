@@ -0,0 +1,103 @@
+package onvif
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Preset of the PTZ service
+type Preset struct {
+	Token string `xml:"token,attr"`
+	Name  string `xml:"Name"`
+}
+
+// Vector in the generic spaces: pan/tilt -1..1, zoom 0..1 (position) or -1..1 (velocity)
+type Vector struct {
+	Pan  float64
+	Tilt float64
+	Zoom float64
+}
+
+// Status of the PTZ service
+type Status struct {
+	Position      Vector
+	PanTiltMoving bool
+	ZoomMoving    bool
+}
+
+// ContinuousMove moves with velocity (pan: -1 left..1 right, tilt: -1 down..1 up, zoom: -1 wide..1 tele)
+func (c *Client) ContinuousMove(velocity Vector) error {
+	return c.ptz("ContinuousMove", `<Velocity>`+vector(velocity)+`</Velocity>`, nil)
+}
+
+// Stop stops pan/tilt and/or zoom movement
+func (c *Client) Stop(panTilt, zoom bool) error {
+	return c.ptz("Stop", `<PanTilt>`+strconv.FormatBool(panTilt)+`</PanTilt><Zoom>`+strconv.FormatBool(zoom)+`</Zoom>`, nil)
+}
+
+// AbsoluteMove moves to position (pan/tilt -1..1, zoom 0..1)
+func (c *Client) AbsoluteMove(position Vector) error {
+	return c.ptz("AbsoluteMove", `<Position>`+vector(position)+`</Position>`, nil)
+}
+
+// GotoPreset moves to the preset with token
+func (c *Client) GotoPreset(token string) error {
+	return c.ptz("GotoPreset", `<PresetToken>`+escape(token)+`</PresetToken>`, nil)
+}
+
+// SetPreset stores the current position as preset (empty token = new preset) and returns the token
+func (c *Client) SetPreset(name, token string) (string, error) {
+	body := `<PresetName>` + escape(name) + `</PresetName>`
+	if token != "" {
+		body += `<PresetToken>` + escape(token) + `</PresetToken>`
+	}
+	var resp struct {
+		Token string `xml:"Body>SetPresetResponse>PresetToken"`
+	}
+	err := c.ptz("SetPreset", body, &resp)
+	return resp.Token, err
+}
+
+// GetPresets returns all presets of the profile
+func (c *Client) GetPresets() ([]Preset, error) {
+	var resp struct {
+		Presets []Preset `xml:"Body>GetPresetsResponse>Preset"`
+	}
+	err := c.ptz("GetPresets", "", &resp)
+	return resp.Presets, err
+}
+
+// GetStatus returns position and move status
+func (c *Client) GetStatus() (s Status, err error) {
+	var resp struct {
+		PanTilt struct {
+			X float64 `xml:"x,attr"`
+			Y float64 `xml:"y,attr"`
+		} `xml:"Body>GetStatusResponse>PTZStatus>Position>PanTilt"`
+		Zoom struct {
+			X float64 `xml:"x,attr"`
+		} `xml:"Body>GetStatusResponse>PTZStatus>Position>Zoom"`
+		PanTiltStatus string `xml:"Body>GetStatusResponse>PTZStatus>MoveStatus>PanTilt"`
+		ZoomStatus    string `xml:"Body>GetStatusResponse>PTZStatus>MoveStatus>Zoom"`
+	}
+	if err = c.ptz("GetStatus", "", &resp); err != nil {
+		return
+	}
+	s.Position = Vector{Pan: resp.PanTilt.X, Tilt: resp.PanTilt.Y, Zoom: resp.Zoom.X}
+	s.PanTiltMoving = resp.PanTiltStatus == "MOVING"
+	s.ZoomMoving = resp.ZoomStatus == "MOVING"
+	return
+}
+
+// call PTZ operation of the profile
+func (c *Client) ptz(operation, body string, resp interface{}) error {
+	if c.ptzURL == "" {
+		return fmt.Errorf("onvif: %v not connected", c.DeviceURL)
+	}
+	return c.call(c.ptzURL, nsPTZ+"/"+operation,
+		`<`+operation+` xmlns="`+nsPTZ+`"><ProfileToken>`+escape(c.ProfileToken)+`</ProfileToken>`+body+`</`+operation+`>`, resp)
+}
+
+func vector(v Vector) string {
+	return fmt.Sprintf(`<PanTilt x="%.4f" y="%.4f" xmlns="%s"/><Zoom x="%.4f" xmlns="%s"/>`, v.Pan, v.Tilt, nsSchema, v.Zoom, nsSchema)
+}
@@ -0,0 +1,237 @@
+package pelcod
+
+import "fmt"
+
+// command 2 of extended commands (always odd)
+const (
+	opSetPreset       = 0x03
+	opClearPreset     = 0x05
+	opGotoPreset      = 0x07
+	opSetAux          = 0x09
+	opClearAux        = 0x0b
+	opRemoteReset     = 0x0f
+	opStartPattern    = 0x1f
+	opStopPattern     = 0x21
+	opRunPattern      = 0x23
+	opSetZoomSpeed    = 0x25
+	opSetFocusSpeed   = 0x27
+	opFactoryDefault  = 0x29
+	opAutoFocus       = 0x2b
+	opAutoIris        = 0x2d
+	opAGC             = 0x2f
+	opBacklight       = 0x31
+	opAutoWhite       = 0x33
+	opZeroPan         = 0x49
+	opSetPanPosition  = 0x4b
+	opSetTiltPosition = 0x4d
+	opSetZoomPosition = 0x4f
+	opQueryPan        = 0x51
+	opQueryTilt       = 0x53
+	opQueryZoom       = 0x55
+	opPanPosition     = 0x59
+	opTiltPosition    = 0x5b
+	opZoomPosition    = 0x5d
+)
+
+// Mode of automatic camera functions (auto focus, auto iris, AGC)
+type Mode byte
+
+const (
+	ModeAuto Mode = 0
+	ModeOn   Mode = 1
+	ModeOff  Mode = 2
+)
+
+// SetPreset stores the current position as preset
+type SetPreset struct{ Preset byte }
+
+// ClearPreset deletes a stored preset
+type ClearPreset struct{ Preset byte }
+
+// GotoPreset moves to a stored preset
+type GotoPreset struct{ Preset byte }
+
+// SetAux switches an auxiliary output on
+type SetAux struct{ Aux byte }
+
+// ClearAux switches an auxiliary output off
+type ClearAux struct{ Aux byte }
+
+// RemoteReset restarts the camera
+type RemoteReset struct{}
+
+// StartPattern starts recording a pattern
+type StartPattern struct{ Pattern byte }
+
+// StopPattern stops recording a pattern
+type StopPattern struct{ Pattern byte }
+
+// RunPattern plays a recorded pattern
+type RunPattern struct{ Pattern byte }
+
+// SetZoomSpeed selects the zoom speed 0 (slowest)..3 (fastest)
+type SetZoomSpeed struct{ Speed byte }
+
+// SetFocusSpeed selects the focus speed 0 (slowest)..3 (fastest)
+type SetFocusSpeed struct{ Speed byte }
+
+// FactoryDefault resets the camera to its factory settings
+type FactoryDefault struct{}
+
+// AutoFocus selects the auto focus mode
+type AutoFocus struct{ Mode Mode }
+
+// AutoIris selects the auto iris mode
+type AutoIris struct{ Mode Mode }
+
+// AGC selects the automatic gain control mode
+type AGC struct{ Mode Mode }
+
+// Backlight switches the backlight compensation
+type Backlight struct{ On bool }
+
+// AutoWhiteBalance switches the automatic white balance
+type AutoWhiteBalance struct{ On bool }
+
+// ZeroPan sets the current pan position as zero position
+type ZeroPan struct{}
+
+// SetPanPosition moves to an absolute pan position (hundredths of degree)
+type SetPanPosition struct{ Position uint16 }
+
+// SetTiltPosition moves to an absolute tilt position (hundredths of degree)
+type SetTiltPosition struct{ Position uint16 }
+
+// SetZoomPosition moves to an absolute zoom position (0..65535 = wide..tele)
+type SetZoomPosition struct{ Position uint16 }
+
+// QueryPan requests the pan position, camera answers with PanPosition
+type QueryPan struct{}
+
+// QueryTilt requests the tilt position, camera answers with TiltPosition
+type QueryTilt struct{}
+
+// QueryZoom requests the zoom position, camera answers with ZoomPosition
+type QueryZoom struct{}
+
+// PanPosition is the response to QueryPan (hundredths of degree)
+type PanPosition struct{ Position uint16 }
+
+// TiltPosition is the response to QueryTilt (hundredths of degree)
+type TiltPosition struct{ Position uint16 }
+
+// ZoomPosition is the response to QueryZoom
+type ZoomPosition struct{ Position uint16 }
+
+func (c SetPreset) bytes() (byte, byte, byte, byte)      { return 0, opSetPreset, 0, c.Preset }
+func (c ClearPreset) bytes() (byte, byte, byte, byte)    { return 0, opClearPreset, 0, c.Preset }
+func (c GotoPreset) bytes() (byte, byte, byte, byte)     { return 0, opGotoPreset, 0, c.Preset }
+func (c SetAux) bytes() (byte, byte, byte, byte)         { return 0, opSetAux, 0, c.Aux }
+func (c ClearAux) bytes() (byte, byte, byte, byte)       { return 0, opClearAux, 0, c.Aux }
+func (c RemoteReset) bytes() (byte, byte, byte, byte)    { return 0, opRemoteReset, 0, 0 }
+func (c StartPattern) bytes() (byte, byte, byte, byte)   { return 0, opStartPattern, 0, c.Pattern }
+func (c StopPattern) bytes() (byte, byte, byte, byte)    { return 0, opStopPattern, 0, c.Pattern }
+func (c RunPattern) bytes() (byte, byte, byte, byte)     { return 0, opRunPattern, 0, c.Pattern }
+func (c SetZoomSpeed) bytes() (byte, byte, byte, byte)   { return 0, opSetZoomSpeed, 0, c.Speed }
+func (c SetFocusSpeed) bytes() (byte, byte, byte, byte)  { return 0, opSetFocusSpeed, 0, c.Speed }
+func (c FactoryDefault) bytes() (byte, byte, byte, byte) { return 0, opFactoryDefault, 0, 0 }
+func (c AutoFocus) bytes() (byte, byte, byte, byte)      { return 0, opAutoFocus, 0, byte(c.Mode) }
+func (c AutoIris) bytes() (byte, byte, byte, byte)       { return 0, opAutoIris, 0, byte(c.Mode) }
+func (c AGC) bytes() (byte, byte, byte, byte)            { return 0, opAGC, 0, byte(c.Mode) }
+func (c Backlight) bytes() (byte, byte, byte, byte) {
+	if c.On {
+		return 0, opBacklight, 0, 2
+	}
+	return 0, opBacklight, 0, 1
+}
+func (c AutoWhiteBalance) bytes() (byte, byte, byte, byte) {
+	if c.On {
+		return 0, opAutoWhite, 0, 1
+	}
+	return 0, opAutoWhite, 0, 2
+}
+func (c ZeroPan) bytes() (byte, byte, byte, byte) { return 0, opZeroPan, 0, 0 }
+func (c SetPanPosition) bytes() (byte, byte, byte, byte) {
+	return position(opSetPanPosition, c.Position)
+}
+func (c SetTiltPosition) bytes() (byte, byte, byte, byte) {
+	return position(opSetTiltPosition, c.Position)
+}
+func (c SetZoomPosition) bytes() (byte, byte, byte, byte) {
+	return position(opSetZoomPosition, c.Position)
+}
+func (c QueryPan) bytes() (byte, byte, byte, byte)     { return 0, opQueryPan, 0, 0 }
+func (c QueryTilt) bytes() (byte, byte, byte, byte)    { return 0, opQueryTilt, 0, 0 }
+func (c QueryZoom) bytes() (byte, byte, byte, byte)    { return 0, opQueryZoom, 0, 0 }
+func (c PanPosition) bytes() (byte, byte, byte, byte)  { return position(opPanPosition, c.Position) }
+func (c TiltPosition) bytes() (byte, byte, byte, byte) { return position(opTiltPosition, c.Position) }
+func (c ZoomPosition) bytes() (byte, byte, byte, byte) { return position(opZoomPosition, c.Position) }
+
+// command bytes of position commands/responses (data 1 = MSB, data 2 = LSB)
+func position(op byte, p uint16) (byte, byte, byte, byte) {
+	return 0, op, byte(p >> 8), byte(p)
+}
+
+func decodeExtended(cmd1, cmd2, data1, data2 byte) (Command, error) {
+	if cmd1 != 0 {
+		return nil, fmt.Errorf("pelco-d: invalid command 1 %#02x of extended command %#02x", cmd1, cmd2)
+	}
+	word := uint16(data1)<<8 | uint16(data2)
+	switch cmd2 {
+	case opSetPreset:
+		return SetPreset{data2}, nil
+	case opClearPreset:
+		return ClearPreset{data2}, nil
+	case opGotoPreset:
+		return GotoPreset{data2}, nil
+	case opSetAux:
+		return SetAux{data2}, nil
+	case opClearAux:
+		return ClearAux{data2}, nil
+	case opRemoteReset:
+		return RemoteReset{}, nil
+	case opStartPattern:
+		return StartPattern{data2}, nil
+	case opStopPattern:
+		return StopPattern{data2}, nil
+	case opRunPattern:
+		return RunPattern{data2}, nil
+	case opSetZoomSpeed:
+		return SetZoomSpeed{data2}, nil
+	case opSetFocusSpeed:
+		return SetFocusSpeed{data2}, nil
+	case opFactoryDefault:
+		return FactoryDefault{}, nil
+	case opAutoFocus:
+		return AutoFocus{Mode(data2)}, nil
+	case opAutoIris:
+		return AutoIris{Mode(data2)}, nil
+	case opAGC:
+		return AGC{Mode(data2)}, nil
+	case opBacklight:
+		return Backlight{data2 == 2}, nil
+	case opAutoWhite:
+		return AutoWhiteBalance{data2 == 1}, nil
+	case opZeroPan:
+		return ZeroPan{}, nil
+	case opSetPanPosition:
+		return SetPanPosition{word}, nil
+	case opSetTiltPosition:
+		return SetTiltPosition{word}, nil
+	case opSetZoomPosition:
+		return SetZoomPosition{word}, nil
+	case opQueryPan:
+		return QueryPan{}, nil
+	case opQueryTilt:
+		return QueryTilt{}, nil
+	case opQueryZoom:
+		return QueryZoom{}, nil
+	case opPanPosition:
+		return PanPosition{word}, nil
+	case opTiltPosition:
+		return TiltPosition{word}, nil
+	case opZoomPosition:
+		return ZoomPosition{word}, nil
+	}
+	return nil, fmt.Errorf("pelco-d: unknown extended command %#02x", cmd2)
+}
@@ -0,0 +1,110 @@
+// Package pelcod encodes and decodes Pelco-D frames.
+//
+// A frame has 7 bytes: sync (0xff), address, command 1, command 2, data 1, data 2, checksum.
+// The checksum is the sum (modulo 256) of the bytes 2..6 (address..data 2).
+package pelcod
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	Sync      = 0xff // first byte of every frame
+	FrameSize = 7    // length of a frame in bytes
+
+	MaxSpeed      = 0x3f // highest pan/tilt speed
+	TurboSpeed    = 0xff // pan speed "turbo" (not supported by every camera)
+	MaxZoomSpeed  = 0x03 // highest speed of SetZoomSpeed
+	MaxFocusSpeed = 0x03 // highest speed of SetFocusSpeed
+)
+
+// Frame is a complete Pelco-D message including sync byte and checksum
+type Frame [FrameSize]byte
+
+// Command is a typed Pelco-D command which can be encoded to a frame
+type Command interface {
+	// command 1, command 2, data 1, data 2 of the frame
+	bytes() (cmd1, cmd2, data1, data2 byte)
+}
+
+// Encode builds the frame of cmd for camera with given address
+func Encode(address byte, cmd Command) Frame {
+	cmd1, cmd2, data1, data2 := cmd.bytes()
+	f := Frame{Sync, address, cmd1, cmd2, data1, data2, 0}
+	f[6] = f.checksum()
+	return f
+}
+
+// Decode parses a frame and returns the address and the typed command
+func Decode(b []byte) (address byte, cmd Command, err error) {
+	f, err := ParseFrame(b)
+	if err != nil {
+		return 0, nil, err
+	}
+	cmd, err = f.Command()
+	return f.Address(), cmd, err
+}
+
+// ParseFrame checks sync byte, length and checksum of a raw frame
+func ParseFrame(b []byte) (f Frame, err error) {
+	if len(b) != FrameSize {
+		return f, fmt.Errorf("pelco-d: invalid frame length %d: %s", len(b), hex.EncodeToString(b))
+	}
+	copy(f[:], b)
+	if f[0] != Sync {
+		return f, fmt.Errorf("pelco-d: invalid sync byte: %s", f)
+	}
+	if f[6] != f.checksum() {
+		return f, fmt.Errorf("pelco-d: invalid checksum %#02x (expected %#02x): %s", f[6], f.checksum(), f)
+	}
+	return f, nil
+}
+
+// ScanFrames is a split function for bufio.Scanner returning frames of FrameSize bytes starting with
+// the sync byte (e.g. reading responses from a serial port), bytes before the sync byte are skipped
+func ScanFrames(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := bytes.IndexByte(data, Sync)
+	switch {
+	case start < 0:
+		return len(data), nil, nil
+	case start > 0:
+		return start, nil, nil
+	case len(data) >= FrameSize:
+		return FrameSize, data[:FrameSize], nil
+	case atEOF:
+		return len(data), nil, fmt.Errorf("pelco-d: incomplete frame: %s", hex.EncodeToString(data))
+	}
+	return 0, nil, nil
+}
+
+// Address of the camera
+func (f Frame) Address() byte {
+	return f[1]
+}
+
+// Bytes returns the raw frame (e.g. for writing to the port)
+func (f Frame) Bytes() []byte {
+	return f[:]
+}
+
+func (f Frame) String() string {
+	return hex.EncodeToString(f[:])
+}
+
+func (f Frame) checksum() (checksum byte) {
+	for _, v := range f[1:6] {
+		checksum += v
+	}
+	return
+}
+
+// Command returns the typed command of the frame (checksum is not verified, see ParseFrame)
+func (f Frame) Command() (Command, error) {
+	cmd1, cmd2, data1, data2 := f[2], f[3], f[4], f[5]
+	if cmd2&0x01 == 0 {
+		return decodeStandard(cmd1, cmd2, data1, data2)
+	}
+	return decodeExtended(cmd1, cmd2, data1, data2)
+}
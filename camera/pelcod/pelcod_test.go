@@ -0,0 +1,154 @@
+package pelcod
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		address byte
+		cmd     Command
+		frame   string
+	}{
+		{1, Move{}, "ff010000000001"},
+		{1, Move{Pan: PanLeft, PanSpeed: 0x20}, "ff010004200025"},
+		{1, Move{Pan: PanRight, PanSpeed: 0x3f}, "ff0100023f0042"},
+		{2, Move{Tilt: TiltUp, TiltSpeed: 0x3f}, "ff020008003f49"},
+		{1, Move{Tilt: TiltDown, TiltSpeed: 0x10}, "ff010010001021"},
+		{1, Move{Pan: PanRight, Tilt: TiltDown, PanSpeed: 0x20, TiltSpeed: 0x10}, "ff010012201043"},
+		{1, Move{Zoom: ZoomTele}, "ff010020000021"},
+		{1, Move{Zoom: ZoomWide}, "ff010040000041"},
+		{1, Move{Focus: FocusFar}, "ff010080000081"},
+		{1, Move{Focus: FocusNear}, "ff010100000002"},
+		{1, Move{Iris: IrisOpen}, "ff010200000003"},
+		{1, Move{Iris: IrisClose}, "ff010400000005"},
+		{1, Power{On: true}, "ff018800000089"},
+		{1, Power{On: false}, "ff010800000009"},
+		{1, Scan{Auto: true}, "ff019000000091"},
+		{1, SetPreset{Preset: 1}, "ff010003000105"},
+		{1, ClearPreset{Preset: 1}, "ff010005000107"},
+		{1, GotoPreset{Preset: 1}, "ff010007000109"},
+		{1, RemoteReset{}, "ff01000f000010"},
+		{1, SetZoomSpeed{Speed: 3}, "ff010025000329"},
+		{1, QueryPan{}, "ff010051000052"},
+		{1, PanPosition{Position: 0x2331}, "ff0100592331ae"},
+		{1, SetTiltPosition{Position: 9000}, "ff01004d232899"},
+	}
+	for _, tt := range tests {
+		got := Encode(tt.address, tt.cmd).String()
+		if got != tt.frame {
+			t.Errorf("Encode(%d, %#v) = %v, want %v", tt.address, tt.cmd, got, tt.frame)
+		}
+		address, cmd, err := Decode(mustHex(t, tt.frame))
+		if err != nil || address != tt.address || !reflect.DeepEqual(cmd, tt.cmd) {
+			t.Errorf("Decode(%v) = %d, %#v, %v, want %d, %#v", tt.frame, address, cmd, err, tt.address, tt.cmd)
+		}
+	}
+}
+
+// every command decodes to itself
+func TestRoundTrip(t *testing.T) {
+	commands := []Command{
+		Move{},
+		Move{Pan: PanLeft, Tilt: TiltUp, Zoom: ZoomWide, Focus: FocusFar, Iris: IrisClose, PanSpeed: 0x3f, TiltSpeed: 0x01},
+		Move{Pan: PanRight, Tilt: TiltDown, Zoom: ZoomTele, Focus: FocusNear, Iris: IrisOpen, PanSpeed: TurboSpeed, TiltSpeed: 0x3f},
+		Power{On: true},
+		Power{On: false},
+		Scan{Auto: true},
+		Scan{Auto: false},
+		SetPreset{Preset: 0x20},
+		ClearPreset{Preset: 0xff},
+		GotoPreset{Preset: 0x01},
+		SetAux{Aux: 1},
+		ClearAux{Aux: 2},
+		RemoteReset{},
+		StartPattern{Pattern: 1},
+		StopPattern{Pattern: 1},
+		RunPattern{Pattern: 1},
+		SetZoomSpeed{Speed: MaxZoomSpeed},
+		SetFocusSpeed{Speed: MaxFocusSpeed},
+		FactoryDefault{},
+		AutoFocus{Mode: ModeAuto},
+		AutoIris{Mode: ModeOn},
+		AGC{Mode: ModeOff},
+		Backlight{On: true},
+		Backlight{On: false},
+		AutoWhiteBalance{On: true},
+		AutoWhiteBalance{On: false},
+		ZeroPan{},
+		SetPanPosition{Position: 35999},
+		SetTiltPosition{Position: 27000},
+		SetZoomPosition{Position: 0xffff},
+		QueryPan{},
+		QueryTilt{},
+		QueryZoom{},
+		PanPosition{Position: 18000},
+		TiltPosition{Position: 4500},
+		ZoomPosition{Position: 0x1234},
+	}
+	for _, address := range []byte{0, 1, 0xff} {
+		for _, cmd := range commands {
+			f := Encode(address, cmd)
+			a, got, err := Decode(f.Bytes())
+			if err != nil {
+				t.Errorf("Decode(%v) of %#v: %v", f, cmd, err)
+				continue
+			}
+			if a != address || !reflect.DeepEqual(got, cmd) {
+				t.Errorf("Decode(Encode(%d, %#v)) = %d, %#v", address, cmd, a, got)
+			}
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+	}{
+		{"checksum", "ff010004200026"},
+		{"sync byte", "fe010004200025"},
+		{"short frame", "ff0100042000"},
+		{"long frame", "ff01000420002500"},
+		{"both pan directions", "ff010006200027"},
+		{"both focus directions", "ff010180000082"},
+		{"unknown extended command", "ff0100fd0000fe"},
+		{"extended command with command 1", "ff010103000105"},
+	}
+	for _, tt := range tests {
+		if _, cmd, err := Decode(mustHex(t, tt.frame)); err == nil {
+			t.Errorf("%v: Decode(%v) = %#v, want error", tt.name, tt.frame, cmd)
+		}
+	}
+}
+
+// frames are found after garbage and split across reads
+func TestScanFrames(t *testing.T) {
+	data := mustHex(t, "0102ff010004200025ff0100592331aeff01")
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Split(ScanFrames)
+	var frames []string
+	for s.Scan() {
+		frames = append(frames, hex.EncodeToString(s.Bytes()))
+	}
+	want := []string{"ff010004200025", "ff0100592331ae"}
+	if !reflect.DeepEqual(frames, want) {
+		t.Errorf("frames = %v, want %v", frames, want)
+	}
+	if s.Err() == nil {
+		t.Errorf("incomplete frame at EOF: no error")
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
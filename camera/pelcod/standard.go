@@ -0,0 +1,137 @@
+package pelcod
+
+import "fmt"
+
+// Direction of an axis in a standard command
+type Direction int8
+
+const (
+	Stop Direction = 0
+
+	PanRight  Direction = 1
+	PanLeft   Direction = -1
+	TiltUp    Direction = 1
+	TiltDown  Direction = -1
+	ZoomTele  Direction = 1
+	ZoomWide  Direction = -1
+	FocusFar  Direction = 1
+	FocusNear Direction = -1
+	IrisOpen  Direction = 1
+	IrisClose Direction = -1
+)
+
+// command 1 bits of standard commands
+const (
+	bitFocusNear = 0x01
+	bitIrisOpen  = 0x02
+	bitIrisClose = 0x04
+	bitCamera    = 0x08 // camera on/off (depending on sense bit)
+	bitScan      = 0x10 // auto/manual scan (depending on sense bit)
+	bitSense     = 0x80
+)
+
+// command 2 bits of standard commands
+const (
+	bitPanRight = 0x02
+	bitPanLeft  = 0x04
+	bitTiltUp   = 0x08
+	bitTiltDown = 0x10
+	bitZoomTele = 0x20
+	bitZoomWide = 0x40
+	bitFocusFar = 0x80
+)
+
+// Move is the standard pan/tilt/zoom/focus/iris command, all zero stops every motion
+type Move struct {
+	Pan   Direction
+	Tilt  Direction
+	Zoom  Direction
+	Focus Direction
+	Iris  Direction
+
+	PanSpeed  byte // 0x00..0x3f, 0xff turbo
+	TiltSpeed byte // 0x00..0x3f
+}
+
+func (m Move) bytes() (cmd1, cmd2, data1, data2 byte) {
+	cmd1 |= bits(m.Focus, 0, bitFocusNear)
+	cmd2 |= bits(m.Focus, bitFocusFar, 0)
+	cmd1 |= bits(m.Iris, bitIrisOpen, bitIrisClose)
+	cmd2 |= bits(m.Pan, bitPanRight, bitPanLeft)
+	cmd2 |= bits(m.Tilt, bitTiltUp, bitTiltDown)
+	cmd2 |= bits(m.Zoom, bitZoomTele, bitZoomWide)
+	return cmd1, cmd2, m.PanSpeed, m.TiltSpeed
+}
+
+func bits(d Direction, positive, negative byte) byte {
+	switch {
+	case d > 0:
+		return positive
+	case d < 0:
+		return negative
+	}
+	return 0
+}
+
+// Power switches the camera on or off
+type Power struct {
+	On bool
+}
+
+func (p Power) bytes() (cmd1, cmd2, data1, data2 byte) {
+	if p.On {
+		return bitSense | bitCamera, 0, 0, 0
+	}
+	return bitCamera, 0, 0, 0
+}
+
+// Scan switches between auto and manual scan
+type Scan struct {
+	Auto bool
+}
+
+func (s Scan) bytes() (cmd1, cmd2, data1, data2 byte) {
+	if s.Auto {
+		return bitSense | bitScan, 0, 0, 0
+	}
+	return bitScan, 0, 0, 0
+}
+
+func decodeStandard(cmd1, cmd2, data1, data2 byte) (Command, error) {
+	switch {
+	case cmd1&bitCamera != 0:
+		return Power{On: cmd1&bitSense != 0}, nil
+	case cmd1&bitScan != 0:
+		return Scan{Auto: cmd1&bitSense != 0}, nil
+	}
+	var err error
+	m := Move{PanSpeed: data1, TiltSpeed: data2}
+	if m.Focus, err = direction(cmd2&bitFocusFar != 0, cmd1&bitFocusNear != 0); err != nil {
+		return nil, fmt.Errorf("pelco-d: focus %v", err)
+	}
+	if m.Iris, err = direction(cmd1&bitIrisOpen != 0, cmd1&bitIrisClose != 0); err != nil {
+		return nil, fmt.Errorf("pelco-d: iris %v", err)
+	}
+	if m.Pan, err = direction(cmd2&bitPanRight != 0, cmd2&bitPanLeft != 0); err != nil {
+		return nil, fmt.Errorf("pelco-d: pan %v", err)
+	}
+	if m.Tilt, err = direction(cmd2&bitTiltUp != 0, cmd2&bitTiltDown != 0); err != nil {
+		return nil, fmt.Errorf("pelco-d: tilt %v", err)
+	}
+	if m.Zoom, err = direction(cmd2&bitZoomTele != 0, cmd2&bitZoomWide != 0); err != nil {
+		return nil, fmt.Errorf("pelco-d: zoom %v", err)
+	}
+	return m, nil
+}
+
+func direction(positive, negative bool) (Direction, error) {
+	switch {
+	case positive && negative:
+		return Stop, fmt.Errorf("has both directions set")
+	case positive:
+		return 1, nil
+	case negative:
+		return -1, nil
+	}
+	return Stop, nil
+}
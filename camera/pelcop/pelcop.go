@@ -0,0 +1,173 @@
+// Package pelcop encodes and decodes Pelco-P frames.
+//
+// Pelco-P uses the command set of Pelco-D (see package pelcod) with a different frame:
+// STX (0xa0), address (0-based), data 1, data 2, data 3, data 4, ETX (0xaf), checksum.
+// The checksum is the XOR of the bytes 1..7 (STX..ETX).
+// Extended commands use the Pelco-D bytes as is, standard commands have a different bit layout of data 1.
+package pelcop
+
+import (
+	"bytes"
+	"camcontrol/camera/pelcod"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const (
+	STX       = 0xa0 // first byte of every frame
+	ETX       = 0xaf // 7th byte of every frame
+	FrameSize = 8    // length of a frame in bytes
+)
+
+// ErrNotSupported is returned by Encode for Pelco-D commands without Pelco-P bits (manual scan)
+var ErrNotSupported = errors.New("pelco-p: command not supported")
+
+// Frame is a complete Pelco-P message including STX, ETX and checksum
+type Frame [FrameSize]byte
+
+// data 1 bits of standard commands
+const (
+	bitFocusFar  = 0x01
+	bitFocusNear = 0x02
+	bitIrisOpen  = 0x04
+	bitIrisClose = 0x08
+	bitCamera    = 0x10 // camera on/off
+	bitAutoScan  = 0x20
+	bitCameraOn  = 0x40
+)
+
+// Pelco-D bits which are mapped to data 1
+const (
+	dFocusNear = 0x01 // command 1
+	dIrisOpen  = 0x02 // command 1
+	dIrisClose = 0x04 // command 1
+	dCamera    = 0x08 // command 1
+	dScan      = 0x10 // command 1
+	dSense     = 0x80 // command 1
+	dFocusFar  = 0x80 // command 2
+)
+
+// Encode builds the frame of cmd for camera with given (0-based) address
+func Encode(address byte, cmd pelcod.Command) (Frame, error) {
+	if s, ok := cmd.(pelcod.Scan); ok && !s.Auto {
+		// auto scan is switched off by a stop (data 1 has no manual scan bit)
+		return Frame{}, fmt.Errorf("%w: manual scan", ErrNotSupported)
+	}
+	d := pelcod.Encode(0, cmd)
+	f := Frame{STX, address, d[2], d[3], d[4], d[5], ETX, 0}
+	if d[3]&0x01 == 0 {
+		// standard command
+		f[2], f[3] = 0, d[3]&^dFocusFar
+		for _, m := range []struct {
+			set bool
+			bit byte
+		}{
+			{d[3]&dFocusFar != 0, bitFocusFar},
+			{d[2]&dFocusNear != 0, bitFocusNear},
+			{d[2]&dIrisOpen != 0, bitIrisOpen},
+			{d[2]&dIrisClose != 0, bitIrisClose},
+			{d[2]&dCamera != 0, bitCamera},
+			{d[2]&dCamera != 0 && d[2]&dSense != 0, bitCameraOn},
+			{d[2]&dScan != 0 && d[2]&dSense != 0, bitAutoScan},
+		} {
+			if m.set {
+				f[2] |= m.bit
+			}
+		}
+	}
+	f[7] = f.checksum()
+	return f, nil
+}
+
+// Decode parses a frame and returns the (0-based) address and the typed Pelco-D command
+func Decode(b []byte) (address byte, cmd pelcod.Command, err error) {
+	f, err := ParseFrame(b)
+	if err != nil {
+		return 0, nil, err
+	}
+	d := []byte{pelcod.Sync, 0, f[2], f[3], f[4], f[5], 0}
+	if f[3]&0x01 == 0 {
+		// standard command
+		d[2], d[3] = 0, f[3]
+		if f[2]&bitFocusFar != 0 {
+			d[3] |= dFocusFar
+		}
+		for _, m := range []struct {
+			set  bool
+			bits byte
+		}{
+			{f[2]&bitFocusNear != 0, dFocusNear},
+			{f[2]&bitIrisOpen != 0, dIrisOpen},
+			{f[2]&bitIrisClose != 0, dIrisClose},
+			{f[2]&bitCamera != 0, dCamera},
+			{f[2]&bitCameraOn != 0, dSense},
+			{f[2]&bitAutoScan != 0, dScan | dSense},
+		} {
+			if m.set {
+				d[2] |= m.bits
+			}
+		}
+	}
+	for _, v := range d[1:6] {
+		d[6] += v
+	}
+	_, cmd, err = pelcod.Decode(d)
+	if err != nil {
+		err = fmt.Errorf("pelco-p: %v (%s)", err, f)
+	}
+	return f.Address(), cmd, err
+}
+
+// ParseFrame checks STX, ETX, length and checksum of a raw frame
+func ParseFrame(b []byte) (f Frame, err error) {
+	if len(b) != FrameSize {
+		return f, fmt.Errorf("pelco-p: invalid frame length %d: %s", len(b), hex.EncodeToString(b))
+	}
+	copy(f[:], b)
+	if f[0] != STX || f[6] != ETX {
+		return f, fmt.Errorf("pelco-p: invalid STX/ETX: %s", f)
+	}
+	if f[7] != f.checksum() {
+		return f, fmt.Errorf("pelco-p: invalid checksum %#02x (expected %#02x): %s", f[7], f.checksum(), f)
+	}
+	return f, nil
+}
+
+// ScanFrames is a split function for bufio.Scanner returning frames of FrameSize bytes starting with
+// STX (e.g. reading responses from a serial port), bytes before STX are skipped
+func ScanFrames(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := bytes.IndexByte(data, STX)
+	switch {
+	case start < 0:
+		return len(data), nil, nil
+	case start > 0:
+		return start, nil, nil
+	case len(data) >= FrameSize:
+		return FrameSize, data[:FrameSize], nil
+	case atEOF:
+		return len(data), nil, fmt.Errorf("pelco-p: incomplete frame: %s", hex.EncodeToString(data))
+	}
+	return 0, nil, nil
+}
+
+// Address of the camera (0-based)
+func (f Frame) Address() byte {
+	return f[1]
+}
+
+// Bytes returns the raw frame (e.g. for writing to the port)
+func (f Frame) Bytes() []byte {
+	return f[:]
+}
+
+func (f Frame) String() string {
+	return hex.EncodeToString(f[:])
+}
+
+func (f Frame) checksum() (checksum byte) {
+	for _, v := range f[:7] {
+		checksum ^= v
+	}
+	return
+}
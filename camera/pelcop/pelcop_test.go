@@ -0,0 +1,105 @@
+package pelcop
+
+import (
+	"bufio"
+	"bytes"
+	"camcontrol/camera/pelcod"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		address byte // 0-based (camera 1 = 0)
+		cmd     pelcod.Command
+		frame   string
+	}{
+		{0, pelcod.Move{}, "a00000000000af0f"},
+		{0, pelcod.Move{Pan: pelcod.PanLeft, PanSpeed: 0x20}, "a00000042000af2b"},
+		{1, pelcod.Move{Pan: pelcod.PanRight, PanSpeed: 0x3f}, "a00100023f00af33"},
+		{2, pelcod.Move{Tilt: pelcod.TiltUp, TiltSpeed: 0x3f}, "a0020008003faf3a"},
+		{0, pelcod.Move{Tilt: pelcod.TiltDown, TiltSpeed: 0x10}, "a00000100010af0f"},
+		{0, pelcod.Move{Zoom: pelcod.ZoomTele}, "a00000200000af2f"},
+		{0, pelcod.Move{Zoom: pelcod.ZoomWide}, "a00000400000af4f"},
+		// focus, iris and camera bits are in data 1 (Pelco-D: command 1 and bit 7 of command 2)
+		{0, pelcod.Move{Focus: pelcod.FocusFar}, "a00001000000af0e"},
+		{0, pelcod.Move{Focus: pelcod.FocusNear}, "a00002000000af0d"},
+		{0, pelcod.Move{Iris: pelcod.IrisOpen}, "a00004000000af0b"},
+		{0, pelcod.Move{Iris: pelcod.IrisClose}, "a00008000000af07"},
+		{0, pelcod.Power{On: true}, "a00050000000af5f"},
+		{0, pelcod.Power{On: false}, "a00010000000af1f"},
+		{0, pelcod.Scan{Auto: true}, "a00020000000af2f"},
+		// extended commands like Pelco-D
+		{0, pelcod.SetPreset{Preset: 1}, "a00000030001af0d"},
+		{0, pelcod.GotoPreset{Preset: 1}, "a00000070001af09"},
+		{0xfe, pelcod.ClearPreset{Preset: 2}, "a0fe00050002aff6"},
+	}
+	for _, tt := range tests {
+		f, err := Encode(tt.address, tt.cmd)
+		if err != nil || f.String() != tt.frame {
+			t.Errorf("Encode(%d, %#v) = %v, %v, want %v", tt.address, tt.cmd, f, err, tt.frame)
+		}
+		address, cmd, err := Decode(mustHex(t, tt.frame))
+		if err != nil || address != tt.address || !reflect.DeepEqual(cmd, tt.cmd) {
+			t.Errorf("Decode(%v) = %d, %#v, %v, want %d, %#v", tt.frame, address, cmd, err, tt.address, tt.cmd)
+		}
+	}
+}
+
+// manual scan has no bit in data 1
+func TestEncodeNotSupported(t *testing.T) {
+	if f, err := Encode(0, pelcod.Scan{Auto: false}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Encode(manual scan) = %v, %v, want ErrNotSupported", f, err)
+	}
+}
+
+func TestParseFrameErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+	}{
+		{"checksum", "a00000042000af2c"},
+		{"checksum of Pelco-D sum", "a00000042000afcb"},
+		{"STX", "a10000042000af2a"},
+		{"ETX", "a00000042000ae2a"},
+		{"short frame", "a00000042000af"},
+		{"long frame", "a00000042000af2b00"},
+	}
+	for _, tt := range tests {
+		if f, err := ParseFrame(mustHex(t, tt.frame)); err == nil {
+			t.Errorf("%v: ParseFrame(%v) = %v, want error", tt.name, tt.frame, f)
+		}
+	}
+	if _, err := ParseFrame(mustHex(t, "a00000042000af2b")); err != nil {
+		t.Errorf("ParseFrame: %v", err)
+	}
+}
+
+// frames are found after garbage
+func TestScanFrames(t *testing.T) {
+	data := mustHex(t, "0102a00000042000af2ba00100023f00af33a000")
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Split(ScanFrames)
+	var frames []string
+	for s.Scan() {
+		frames = append(frames, hex.EncodeToString(s.Bytes()))
+	}
+	want := []string{"a00000042000af2b", "a00100023f00af33"}
+	if !reflect.DeepEqual(frames, want) {
+		t.Errorf("frames = %v, want %v", frames, want)
+	}
+	if s.Err() == nil {
+		t.Errorf("incomplete frame at EOF: no error")
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,144 @@
+package camera
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// presets are stored in the camera, the store keeps their positions in a JSON file as well,
+// so they can be restored to a replacement camera or adjusted by number (edit file and restore)
+
+const (
+	presetMoveTimeout = 15 * time.Second       // maximum duration of move to stored position
+	presetMovePoll    = 300 * time.Millisecond // position readout while moving
+	presetTolerance   = 0.01                   // normalized distance of position to target of move
+)
+
+// PresetStore keeps the positions of the presets (preset number -> position)
+type PresetStore struct {
+	path    string
+	mu      sync.Mutex // presets are recorded by UI while restore runs in background
+	Presets map[byte]Position
+}
+
+// LoadPresetStore reads the positions of file path (missing file = empty store)
+func LoadPresetStore(path string) (*PresetStore, error) {
+	s := PresetStore{path: path, Presets: map[byte]Position{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &s, nil
+	}
+	if err != nil {
+		return &s, fmt.Errorf("failed reading presets: %v", err)
+	}
+	if err := json.Unmarshal(data, &s.Presets); err != nil {
+		return &s, fmt.Errorf("failed reading presets %v: %v", path, err)
+	}
+	return &s, nil
+}
+
+// Save writes the positions to the file
+func (s *PresetStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save()
+}
+
+func (s *PresetStore) save() error {
+	data, err := json.MarshalIndent(s.Presets, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed writing presets: %v", err)
+	}
+	return nil
+}
+
+// Len returns the number of stored positions
+func (s *PresetStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.Presets)
+}
+
+// Record reads the current position of the camera and stores it for preset (ErrNotSupported without position query)
+func (s *PresetStore) Record(cam Camera, preset byte) error {
+	p, err := cam.Position()
+	if err != nil {
+		return err
+	}
+	log.Printf("Preset %d position: %v\n", preset, p)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Presets[preset] = p
+	return s.save()
+}
+
+// Restore moves the camera to every stored position and saves it as preset of the camera
+// (positions are copied on start, the store may be changed while restoring)
+func (s *PresetStore) Restore(cam Camera) error {
+	s.mu.Lock()
+	positions := make(map[byte]Position, len(s.Presets))
+	presets := make([]int, 0, len(s.Presets))
+	for preset, p := range s.Presets {
+		positions[preset] = p
+		presets = append(presets, int(preset))
+	}
+	s.mu.Unlock()
+	sort.Ints(presets)
+	for _, preset := range presets {
+		p := positions[byte(preset)]
+		log.Printf("Restore preset %d: %v\n", preset, p)
+		if err := cam.AbsoluteMove(p.Pan, p.Tilt, p.Zoom); err != nil {
+			return fmt.Errorf("failed restoring preset %d: %w", preset, err)
+		}
+		if err := waitStopped(cam, p); err != nil {
+			return fmt.Errorf("failed restoring preset %d: %w", preset, err)
+		}
+		if err := cam.PresetSave(byte(preset)); err != nil {
+			return fmt.Errorf("failed restoring preset %d: %w", preset, err)
+		}
+	}
+	return nil
+}
+
+// wait until the camera reached the target of the absolute move: the position is close to the target
+// (error if the position changed and does not change anymore outside the tolerance: camera stopped elsewhere)
+func waitStopped(cam Camera, target Position) error {
+	last, err := cam.Position()
+	if err != nil {
+		return err
+	}
+	moved := false
+	for deadline := time.Now().Add(presetMoveTimeout); time.Now().Before(deadline); {
+		if near(last, target) {
+			return nil
+		}
+		time.Sleep(presetMovePoll)
+		p, err := cam.Position()
+		if err != nil {
+			return err
+		}
+		if p != last {
+			moved = true
+		} else if moved && !near(p, target) {
+			return fmt.Errorf("camera stopped at %v, not at %v", p, target)
+		}
+		last = p
+	}
+	return fmt.Errorf("camera did not reach position within %v", presetMoveTimeout)
+}
+
+// position p is within tolerance of target
+func near(p, target Position) bool {
+	return math.Abs(p.Pan-target.Pan) <= presetTolerance && math.Abs(p.Tilt-target.Tilt) <= presetTolerance &&
+		math.Abs(p.Zoom-target.Zoom) <= presetTolerance
+}
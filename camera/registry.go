@@ -0,0 +1,78 @@
+package camera
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Registry holds the cameras of the application by name (in order of configuration) and the selected camera
+type Registry struct {
+	mu       sync.Mutex
+	names    []string
+	cameras  map[string]Camera
+	selected string
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{cameras: map[string]Camera{}}
+}
+
+// Add registers cam with unique name, the first camera is selected
+func (r *Registry) Add(name string, cam Camera) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if name == "" {
+		return fmt.Errorf("camera without name")
+	}
+	if _, ok := r.cameras[name]; ok {
+		return fmt.Errorf("camera '%v' already exists", name)
+	}
+	r.names = append(r.names, name)
+	r.cameras[name] = cam
+	if r.selected == "" {
+		r.selected = name
+	}
+	return nil
+}
+
+// Names returns the camera names in order of configuration
+func (r *Registry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.names...)
+}
+
+// Get returns the camera with name (nil = unknown)
+func (r *Registry) Get(name string) Camera {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cameras[name]
+}
+
+// Select changes the selected camera
+func (r *Registry) Select(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.cameras[name]; !ok {
+		return fmt.Errorf("unknown camera '%v'", name)
+	}
+	r.selected = name
+	return nil
+}
+
+// Selected returns name and camera which are selected (nil = no camera)
+func (r *Registry) Selected() (string, Camera) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.selected, r.cameras[r.selected]
+}
+
+// Close closes all cameras
+func (r *Registry) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range r.names {
+		r.cameras[name].Close()
+	}
+}
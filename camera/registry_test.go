@@ -0,0 +1,60 @@
+package camera
+
+import (
+	"reflect"
+	"testing"
+)
+
+// closedCamera counts the Close calls (other methods are not used by the registry)
+type closedCamera struct {
+	Camera
+	closed int
+}
+
+func (c *closedCamera) Close() {
+	c.closed++
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	if name, cam := r.Selected(); name != "" || cam != nil {
+		t.Errorf("empty registry: selected %q %v", name, cam)
+	}
+	altar, stage := &closedCamera{}, &closedCamera{}
+	if err := r.Add("Altar", altar); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add("Stage", stage); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add("Altar", &closedCamera{}); err == nil {
+		t.Errorf("duplicate name accepted")
+	}
+	if err := r.Add("", &closedCamera{}); err == nil {
+		t.Errorf("empty name accepted")
+	}
+	if names := r.Names(); !reflect.DeepEqual(names, []string{"Altar", "Stage"}) {
+		t.Errorf("names %v, want [Altar Stage]", names)
+	}
+
+	if r.Get("Stage") != stage || r.Get("Organ") != nil {
+		t.Errorf("lookup of Stage %v, Organ %v", r.Get("Stage"), r.Get("Organ"))
+	}
+	if name, cam := r.Selected(); name != "Altar" || cam != altar {
+		t.Errorf("first camera is not selected: %q", name)
+	}
+	if err := r.Select("Organ"); err == nil {
+		t.Errorf("unknown camera selected")
+	}
+	if err := r.Select("Stage"); err != nil {
+		t.Fatal(err)
+	}
+	if name, cam := r.Selected(); name != "Stage" || cam != stage {
+		t.Errorf("selected %q, want Stage", name)
+	}
+
+	r.Close()
+	if altar.closed != 1 || stage.closed != 1 {
+		t.Errorf("closed Altar %d, Stage %d times, want once", altar.closed, stage.closed)
+	}
+}
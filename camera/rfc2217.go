@@ -0,0 +1,233 @@
+package camera
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+)
+
+// RFC 2217 (Telnet COM port control) transport for serial servers, e.g. RS-485-to-Ethernet converter of a camera:
+// the line parameters of the serial port are set by the client, data bytes 0xff are escaped (IAC IAC)
+
+// Telnet commands and options
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+	telnetIAC  = 255
+
+	telnetBinary   = 0
+	telnetSGA      = 3  // suppress go ahead
+	telnetComPort  = 44 // COM-PORT-OPTION
+	comSetBaudRate = 1
+	comSetDataSize = 2
+	comSetParity   = 3
+	comSetStopSize = 4
+	comSetControl  = 5
+)
+
+// states of the Telnet decoder
+const (
+	telnetData = iota
+	telnetCommand
+	telnetOption
+	telnetSubnegotiation
+	telnetSubnegotiationIAC
+)
+
+type rfc2217Transport struct {
+	*netTransport
+	config SerialConfig // line parameters of the serial port of the server
+
+	mu     sync.Mutex    // negotiation replies are written by the frame reader
+	telnet *telnetReader // decoder of the opened connection
+}
+
+// telnetReader decodes the data of a connection (read by frame reader only)
+type telnetReader struct {
+	conn    net.Conn
+	reply   func(p []byte) error // write negotiation reply on the connection
+	state   int                  // Telnet decoder
+	command byte                 // WILL, WONT, DO or DONT of option
+}
+
+// NewRFC2217Transport returns the transport of a serial server with RFC 2217 on host:port,
+// the line parameters are the serial parameters of the camera (default of the protocol)
+func NewRFC2217Transport(addr string) Transport {
+	return &rfc2217Transport{netTransport: &netTransport{network: "tcp", addr: addr}}
+}
+
+// line parameters set on open (see Options.link)
+func (t *rfc2217Transport) configure(config SerialConfig) {
+	t.config = config
+}
+
+// connect and set the line parameters of the serial port
+func (t *rfc2217Transport) Open() error {
+	if err := t.netTransport.Open(); err != nil {
+		return err
+	}
+	conn := t.opened()
+	t.mu.Lock()
+	t.telnet = &telnetReader{conn: conn, reply: func(p []byte) error { return t.writeConn(conn, p) }, state: telnetData}
+	t.mu.Unlock()
+	parity, _ := parseParity(t.config.Parity)
+	flow, _ := parseFlowControl(t.config.FlowControl)
+	control := byte(1) // no flow control
+	if flow {
+		control = 3
+	}
+	baud := make([]byte, 4)
+	binary.BigEndian.PutUint32(baud, uint32(t.config.BaudRate))
+	msg := []byte{
+		telnetIAC, telnetWILL, telnetBinary, telnetIAC, telnetDO, telnetBinary,
+		telnetIAC, telnetWILL, telnetSGA, telnetIAC, telnetDO, telnetSGA,
+		telnetIAC, telnetWILL, telnetComPort,
+	}
+	msg = append(msg, comPortOption(comSetBaudRate, baud...)...)
+	msg = append(msg, comPortOption(comSetDataSize, byte(t.config.DataBits))...)
+	msg = append(msg, comPortOption(comSetParity, byte(parity)+1)...) // 1 = none, 2 = odd, 3 = even
+	msg = append(msg, comPortOption(comSetStopSize, byte(t.config.StopBits))...)
+	msg = append(msg, comPortOption(comSetControl, control)...)
+	log.Printf("RFC 2217 set line %v\n", t.config)
+	if err := t.writeRaw(msg); err != nil {
+		t.Close()
+		return fmt.Errorf("rfc2217: set line parameters failed: %v", err)
+	}
+	return nil
+}
+
+// subnegotiation of COM-PORT-OPTION (values are escaped)
+func comPortOption(command byte, value ...byte) []byte {
+	return append(append([]byte{telnetIAC, telnetSB, telnetComPort, command}, escapeIAC(value)...), telnetIAC, telnetSE)
+}
+
+func escapeIAC(data []byte) []byte {
+	escaped := make([]byte, 0, len(data))
+	for _, b := range data {
+		escaped = append(escaped, b)
+		if b == telnetIAC {
+			escaped = append(escaped, telnetIAC)
+		}
+	}
+	return escaped
+}
+
+func (t *rfc2217Transport) writeRaw(p []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := t.netTransport.Write(p)
+	return err
+}
+
+// write to conn (negotiation reply on the connection of the request)
+func (t *rfc2217Transport) writeConn(conn net.Conn, p []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := conn.Write(p)
+	return err
+}
+
+// write data (0xff is escaped)
+func (t *rfc2217Transport) Write(p []byte) (int, error) {
+	if err := t.writeRaw(escapeIAC(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (t *rfc2217Transport) Read(p []byte) (int, error) {
+	r := t.reader()
+	if r == nil {
+		return 0, io.ErrClosedPipe
+	}
+	return r.Read(p)
+}
+
+func (t *rfc2217Transport) reader() io.Reader {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.telnet == nil || t.opened() != t.telnet.conn {
+		return nil
+	}
+	return t.telnet
+}
+
+// read data without Telnet commands (negotiations are answered, notifications of the server are ignored)
+func (t *telnetReader) Read(p []byte) (int, error) {
+	n, err := connReader{t.conn}.Read(p)
+	data := p[:0]
+	for _, b := range p[:n] {
+		switch t.state {
+		case telnetData:
+			if b == telnetIAC {
+				t.state = telnetCommand
+			} else {
+				data = append(data, b)
+			}
+		case telnetCommand:
+			switch b {
+			case telnetIAC:
+				data = append(data, b)
+				t.state = telnetData
+			case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+				t.command = b
+				t.state = telnetOption
+			case telnetSB:
+				t.state = telnetSubnegotiation
+			default:
+				t.state = telnetData
+			}
+		case telnetOption:
+			t.negotiate(t.command, b)
+			t.state = telnetData
+		case telnetSubnegotiation:
+			if b == telnetIAC {
+				t.state = telnetSubnegotiationIAC
+			}
+		case telnetSubnegotiationIAC:
+			t.state = telnetSubnegotiation
+			if b == telnetSE {
+				t.state = telnetData
+			}
+		}
+	}
+	if len(data) == 0 && err == nil {
+		// only Telnet commands (no data like inter character timeout)
+		return 0, io.EOF
+	}
+	return len(data), err
+}
+
+// refuse options of the server which are not required (binary, suppress go ahead and COM port are requested on open)
+func (t *telnetReader) negotiate(command, option byte) {
+	if option == telnetBinary || option == telnetSGA || option == telnetComPort {
+		return
+	}
+	var reply byte
+	switch command {
+	case telnetWILL:
+		reply = telnetDONT
+	case telnetDO:
+		reply = telnetWONT
+	default:
+		return
+	}
+	if err := t.reply([]byte{telnetIAC, reply, option}); err != nil {
+		log.Printf("rfc2217: negotiation failed: %v\n", err)
+	}
+}
+
+func (t *rfc2217Transport) Address() string {
+	return "rfc2217://" + t.addr
+}
+
+func (t *rfc2217Transport) String() string {
+	return t.Address() + " " + t.config.String()
+}
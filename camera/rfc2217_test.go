@@ -0,0 +1,83 @@
+package camera
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// serial server with RFC 2217: line parameters, escaped data and negotiation of the server
+func TestRFC2217(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	// read n bytes of conn and compare with hex string want
+	expect := func(conn net.Conn, step, want string) {
+		t.Helper()
+		b := make([]byte, len(want)/2)
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		if _, err := io.ReadFull(conn, b); err != nil {
+			t.Errorf("%v: %v", step, err)
+		} else if got := hex.EncodeToString(b); got != want {
+			t.Errorf("%v: %v, want %v", step, got, want)
+		}
+	}
+	written := make(chan bool)
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		expect(conn, "negotiation", "fffb00fffd00fffb03fffd03fffb2c"+
+			"fffa2c0100009600fff0"+ // SET-BAUDRATE 38400
+			"fffa2c0207fff0"+ // SET-DATASIZE 7
+			"fffa2c0303fff0"+ // SET-PARITY even
+			"fffa2c0402fff0"+ // SET-STOPSIZE 2
+			"fffa2c0503fff0") // SET-CONTROL hardware flow control
+		<-written
+		expect(conn, "data", "01ffff02")
+		// option of server, line state notification and data 0xff 0x03
+		_, _ = conn.Write([]byte{0xff, 0xfb, 0x05, 0xff, 0xfa, 0x2c, 0x6b, 0x00, 0xff, 0xf0, 0xff, 0xff, 0x03})
+		expect(conn, "refused option", "fffe05")
+	}()
+
+	tr := NewRFC2217Transport(ln.Addr().String()).(*rfc2217Transport)
+	tr.configure(SerialConfig{BaudRate: 38400, DataBits: 7, Parity: "even", StopBits: 2, FlowControl: "rtscts"})
+	if err := tr.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+	if n, err := tr.Write([]byte{0x01, 0xff, 0x02}); n != 3 || err != nil {
+		t.Errorf("Write = %d, %v", n, err)
+	}
+	close(written)
+	var data []byte
+	buf := make([]byte, 16)
+	for deadline := time.Now().Add(time.Second); len(data) < 2 && time.Now().Before(deadline); {
+		n, err := tr.Read(buf)
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		data = append(data, buf[:n]...)
+	}
+	if !bytes.Equal(data, []byte{0xff, 0x03}) {
+		t.Errorf("Read = %x, want ff03", data)
+	}
+	<-done
+}
+
+// values of subnegotiations are escaped
+func TestComPortOption(t *testing.T) {
+	if got := hex.EncodeToString(comPortOption(comSetBaudRate, 0x00, 0x00, 0xff, 0x00)); got != "fffa2c010000ffff00fff0" {
+		t.Errorf("comPortOption = %v", got)
+	}
+}
@@ -0,0 +1,127 @@
+package camera
+
+import (
+	"camcontrol/device"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jacobsa/go-serial/serial"
+)
+
+// SerialConfig is the serial port of a camera with its line parameters (zero values = default of protocol)
+type SerialConfig struct {
+	Device      device.Selector // serial adapter (empty = search for first CH340/CH341, see device.Resolve)
+	BaudRate    uint            // e.g. 2400, 4800, 9600, 38400
+	DataBits    uint            // 5..8 (default 8)
+	StopBits    uint            // 1 or 2 (default 1)
+	Parity      string          // "none" (default), "odd" or "even"
+	FlowControl string          // "none" (default) or "rtscts" (hardware)
+	ReadTimeout time.Duration   // minimum wait for responses (0 = deadline of command)
+	FrameDelay  time.Duration   // minimum pause between frames (0 = none)
+}
+
+var baudRates = []uint{1200, 2400, 4800, 9600, 19200, 38400, 57600, 115200}
+
+// Validate checks the line parameters
+func (c SerialConfig) Validate() error {
+	if c.BaudRate != 0 && !containsUint(baudRates, c.BaudRate) {
+		return fmt.Errorf("invalid baud rate %d (%v)", c.BaudRate, baudRates)
+	}
+	if c.DataBits != 0 && (c.DataBits < 5 || c.DataBits > 8) {
+		return fmt.Errorf("invalid data bits %d (5..8)", c.DataBits)
+	}
+	if c.StopBits != 0 && c.StopBits != 1 && c.StopBits != 2 {
+		return fmt.Errorf("invalid stop bits %d (1 or 2)", c.StopBits)
+	}
+	if _, err := parseParity(c.Parity); err != nil {
+		return err
+	}
+	if _, err := parseFlowControl(c.FlowControl); err != nil {
+		return err
+	}
+	if c.ReadTimeout < 0 {
+		return fmt.Errorf("invalid read timeout %v", c.ReadTimeout)
+	}
+	if c.FrameDelay < 0 {
+		return fmt.Errorf("invalid frame delay %v", c.FrameDelay)
+	}
+	return nil
+}
+
+func (c SerialConfig) String() string {
+	parity := map[serial.ParityMode]string{serial.PARITY_NONE: "N", serial.PARITY_ODD: "O", serial.PARITY_EVEN: "E"}
+	p, _ := parseParity(c.Parity)
+	return fmt.Sprintf("%d %d%s%d flow=%s timeout=%v delay=%v device='%v'", c.BaudRate, c.DataBits,
+		parity[p], c.StopBits, c.FlowControl, c.ReadTimeout, c.FrameDelay, c.Device)
+}
+
+// fill zero values with defaults (baud rate of protocol, 8N1, no flow control)
+func (c SerialConfig) withDefaults(baudRate uint) SerialConfig {
+	if c.BaudRate == 0 {
+		c.BaudRate = baudRate
+	}
+	if c.DataBits == 0 {
+		c.DataBits = 8
+	}
+	if c.StopBits == 0 {
+		c.StopBits = 1
+	}
+	if c.Parity == "" {
+		c.Parity = "none"
+	}
+	if c.FlowControl == "" {
+		c.FlowControl = "none"
+	}
+	return c
+}
+
+// open options of port (config is validated), read returns after 100ms without data (see frameReader)
+func (c SerialConfig) options(portName string) serial.OpenOptions {
+	parity, _ := parseParity(c.Parity)
+	flow, _ := parseFlowControl(c.FlowControl)
+	options := serial.OpenOptions{
+		PortName:          portName,
+		BaudRate:          c.BaudRate,
+		DataBits:          c.DataBits,
+		StopBits:          c.StopBits,
+		ParityMode:        parity,
+		RTSCTSFlowControl: flow,
+
+		InterCharacterTimeout: 100,
+		MinimumReadSize:       0,
+	}
+	return options
+}
+
+func parseParity(s string) (serial.ParityMode, error) {
+	switch strings.ToLower(s) {
+	case "", "n", "none":
+		return serial.PARITY_NONE, nil
+	case "o", "odd":
+		return serial.PARITY_ODD, nil
+	case "e", "even":
+		return serial.PARITY_EVEN, nil
+	}
+	return serial.PARITY_NONE, fmt.Errorf("invalid parity '%v' (none, odd, even)", s)
+}
+
+// RTS/CTS flow control
+func parseFlowControl(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return false, nil
+	case "rtscts", "hardware":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid flow control '%v' (none, rtscts)", s)
+}
+
+func containsUint(list []uint, v uint) bool {
+	for _, e := range list {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
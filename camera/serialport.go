@@ -0,0 +1,126 @@
+package camera
+
+import (
+	"bufio"
+	"camcontrol/device"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jacobsa/go-serial/serial"
+)
+
+// serial port transport of the camera drivers (USB adapter is searched by device selector, see device.Resolve)
+type serialTransport struct {
+	config SerialConfig // port and line parameters of the camera
+
+	mu   sync.Mutex         // port is read by frame reader
+	port io.ReadWriteCloser // serial port access, nil = closed
+	path string             // opened port
+}
+
+// link on serial port with line parameters of config, baudRate is the default of the protocol (config is validated),
+// split returns the response frames of the protocol (simulation without port, see environment "camera-control")
+func newSerialLink(config SerialConfig, baudRate uint, split bufio.SplitFunc) (*transportLink, error) {
+	log.Printf("'camera-control' setting: %v", os.Getenv("camera-control"))
+	config = config.withDefaults(baudRate)
+	l, err := newTransportLink(&serialTransport{config: config}, split, config.FrameDelay, config.ReadTimeout)
+	if err != nil {
+		return nil, err
+	}
+	l.simulation = os.Getenv("camera-control") == "simulation"
+	return l, nil
+}
+
+// open first port of the device selector which can be opened
+func (s *serialTransport) Open() (err error) {
+	s.Close()
+	names := device.Resolve(s.config.Device)
+	if len(names) == 0 {
+		return fmt.Errorf("serial.Open: failed! no serial port found for device '%v'", s.config.Device)
+	}
+	var options serial.OpenOptions
+	var port io.ReadWriteCloser
+	for _, name := range names {
+		options = s.config.options(name)
+
+		// Open the port.
+		port, err = serial.Open(options)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("serial.Open: failed! port %v (%v)", strings.Join(names, ", "), err)
+	}
+	log.Printf("serial.Open use port %v", options.PortName)
+	s.mu.Lock()
+	s.port, s.path = port, options.PortName
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *serialTransport) Read(p []byte) (int, error) {
+	port := s.opened()
+	if port == nil {
+		return 0, io.ErrClosedPipe
+	}
+	return port.Read(p)
+}
+
+func (s *serialTransport) reader() io.Reader {
+	if port := s.opened(); port != nil {
+		return port
+	}
+	return nil
+}
+
+func (s *serialTransport) Write(p []byte) (int, error) {
+	port := s.opened()
+	if port == nil {
+		return 0, io.ErrClosedPipe
+	}
+	return port.Write(p)
+}
+
+func (s *serialTransport) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.port == nil {
+		return nil
+	}
+	err := s.port.Close()
+	s.port = nil
+	return err
+}
+
+func (s *serialTransport) opened() io.ReadWriteCloser {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.port
+}
+
+// the device selector identifies the serial port
+func (s *serialTransport) Address() string {
+	return "serial " + s.config.Device.String()
+}
+
+func (s *serialTransport) String() string {
+	return "serial " + s.config.String()
+}
+
+// opened port is still available, any port of the device selector is available
+func (s *serialTransport) present() (opened, available bool) {
+	paths := device.Resolve(s.config.Device)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range paths {
+		if s.port != nil && p == s.path {
+			opened = true
+		}
+	}
+	return opened, len(paths) > 0
+}
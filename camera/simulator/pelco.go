@@ -0,0 +1,96 @@
+package simulator
+
+import (
+	"camcontrol/camera/internal/position"
+	"camcontrol/camera/pelcod"
+	"camcontrol/camera/pelcop"
+	"log"
+	"math"
+)
+
+// Pelco-D and Pelco-P: moves, presets, absolute positions and position queries
+// (pan 0..35999 hundredths of degree, tilt positive = down, zoom 0..65535 like the driver)
+
+// decode frame of camera address and return the response frame (nil = no response)
+func (c *Camera) pelco(frame []byte) []byte {
+	var address byte
+	var cmd pelcod.Command
+	var err error
+	if c.protocol == PelcoP {
+		address, cmd, err = pelcop.Decode(frame)
+		address++
+	} else {
+		address, cmd, err = pelcod.Decode(frame)
+	}
+	if err != nil {
+		log.Printf("Simulator: %v\n", err)
+		return nil
+	}
+	if address != c.address {
+		return nil
+	}
+	var response []byte
+	r := c.pelcoCommand(cmd)
+	switch {
+	case r == nil && c.Echo:
+		response = append([]byte{}, frame...)
+	case r == nil:
+		return nil
+	case c.protocol == PelcoP:
+		f, _ := pelcop.Encode(c.address-1, r) // responses are position frames
+		response = f.Bytes()
+	default:
+		response = pelcod.Encode(c.address, r).Bytes()
+	}
+	if c.BadChecksum {
+		// checksum is the last byte of Pelco-D and Pelco-P frames
+		response[len(response)-1]++
+	}
+	return response
+}
+
+func (c *Camera) pelcoCommand(cmd pelcod.Command) pelcod.Command {
+	switch cmd := cmd.(type) {
+	case pelcod.Move:
+		zoom := float64(c.zoomSpeed) / pelcod.MaxZoomSpeed
+		if c.Tenveo && cmd.Pan == pelcod.Stop {
+			zoom = pelcoSpeed(cmd.PanSpeed)
+		}
+		c.drive(float64(cmd.Pan)*pelcoSpeed(cmd.PanSpeed), float64(cmd.Tilt)*pelcoSpeed(cmd.TiltSpeed), float64(cmd.Zoom)*zoom)
+	case pelcod.SetZoomSpeed:
+		c.zoomSpeed = cmd.Speed
+	case pelcod.SetPreset:
+		c.savePreset(cmd.Preset)
+	case pelcod.ClearPreset:
+		delete(c.presets, cmd.Preset)
+	case pelcod.GotoPreset:
+		c.recallPreset(cmd.Preset)
+	case pelcod.SetPanPosition:
+		c.moveAxis(&c.pan, position.SignedDegrees(float64(cmd.Position))/180)
+	case pelcod.SetTiltPosition:
+		c.moveAxis(&c.tilt, -position.SignedDegrees(float64(cmd.Position))/90)
+	case pelcod.SetZoomPosition:
+		c.moveAxis(&c.zoom, float64(cmd.Position)/math.MaxUint16)
+	case pelcod.ZeroPan:
+		c.pan.pos = 0
+	case pelcod.QueryPan:
+		return pelcod.PanPosition{Position: position.Hundredths(c.pan.pos * 180)}
+	case pelcod.QueryTilt:
+		return pelcod.TiltPosition{Position: position.Hundredths(-c.tilt.pos * 90)}
+	case pelcod.QueryZoom:
+		return pelcod.ZoomPosition{Position: uint16(math.Round(c.zoom.pos * math.MaxUint16))}
+	case pelcod.Power:
+		c.power = cmd.On
+		if !c.power {
+			c.drive(0, 0, 0)
+		}
+	case pelcod.RemoteReset:
+		c.reset()
+	}
+	return nil
+}
+
+// pan/tilt speed 0..0x3f (turbo = highest speed) to 0..1
+func pelcoSpeed(speed byte) float64 {
+	return math.Min(1, float64(speed)/pelcod.MaxSpeed)
+}
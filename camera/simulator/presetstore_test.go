@@ -0,0 +1,91 @@
+package simulator
+
+import (
+	"camcontrol/camera"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// run f while the clock advances 10 times faster than real time (the store polls the position in real time)
+func running(clk *clock, f func() error) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				clk.advance(100 * time.Millisecond)
+			}
+		}
+	}()
+	return f()
+}
+
+// positions recorded on one camera are restored as presets of a replacement camera
+func TestPresetStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "presets.json")
+	store, err := camera.LoadPresetStore(path)
+	if err != nil || store.Len() != 0 {
+		t.Fatalf("missing file: %d presets, %v", store.Len(), err)
+	}
+	cam, _, clk := newCamera(t, Visca, 1)
+	for preset, p := range map[byte]camera.Position{1: {Pan: -0.5, Tilt: 0.25, Zoom: 0.75}, 2: {Pan: 0.5, Tilt: -0.5, Zoom: 0.25}} {
+		if err := cam.AbsoluteMove(p.Pan, p.Tilt, p.Zoom); err != nil {
+			t.Fatal(err)
+		}
+		clk.advance(5 * time.Second)
+		if err := store.Record(cam, preset); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err = camera.LoadPresetStore(path)
+	if err != nil || store.Len() != 2 {
+		t.Fatalf("recorded file: %d presets, %v", store.Len(), err)
+	}
+	replacement, sim, clk := newCamera(t, Visca, 2)
+	if err := running(clk, func() error { return store.Restore(replacement) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := replacement.PresetSelect(1); err != nil {
+		t.Fatal(err)
+	}
+	clk.advance(5 * time.Second)
+	if s := sim.State(); !near(s.Pan, -0.5) || !near(s.Tilt, 0.25) || !near(s.Zoom, 0.75) {
+		t.Errorf("preset 1: state %v, want pan -0.50 tilt +0.25 zoom 0.75", s)
+	}
+}
+
+func TestPresetStoreInvalid(t *testing.T) {
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := ioutil.WriteFile(corrupt, []byte(`{"1": {"pan": 0.5,`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if store, err := camera.LoadPresetStore(corrupt); err == nil || store.Len() != 0 {
+		t.Errorf("corrupt file: %d presets, %v, want error", store.Len(), err)
+	}
+
+	// position beyond the range of the camera (e.g. file of other model): camera stops at the limit
+	outside := filepath.Join(dir, "outside.json")
+	if err := ioutil.WriteFile(outside, []byte(`{"1": {"pan": 1.5, "tilt": 0, "zoom": 0}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := camera.LoadPresetStore(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cam, sim, clk := newCamera(t, Visca, 1)
+	if err := running(clk, func() error { return store.Restore(cam) }); err == nil {
+		t.Errorf("restore at pan %+.2f: no error", sim.State().Pan)
+	}
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	if _, ok := sim.presets[1]; ok {
+		t.Errorf("preset saved after failed move")
+	}
+}
@@ -0,0 +1,278 @@
+// Package simulator is a virtual PTZ camera for development and tests without hardware.
+//
+// The simulated camera decodes the frames sent by the Pelco-D, Pelco-P or VISCA driver, moves pan/tilt/zoom
+// over time with the commanded speed (or to the commanded position), stores and recalls presets and answers
+// position queries. It is attached to a driver as transport, e.g.
+//
+//	sim, _ := simulator.New(simulator.PelcoD, 1)
+//	cam, _ := camera.New("pelco-d", camera.Options{Transport: sim.Transport(), Address: 1})
+package simulator
+
+import (
+	"bufio"
+	"camcontrol/camera"
+	"camcontrol/camera/pelcod"
+	"camcontrol/camera/pelcop"
+	"camcontrol/camera/visca"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// Protocol of the simulated camera (name of the camera driver)
+type Protocol string
+
+const (
+	PelcoD      Protocol = "pelco-d"
+	TenveoNV10U Protocol = "tenveo-nv10u" // Pelco-D with the zoom speed of the Tenveo NV10U (see Camera.Tenveo)
+	PelcoP      Protocol = "pelco-p"
+	Visca       Protocol = "visca"
+)
+
+// rates of the axes at highest speed (normalized range per second)
+const (
+	panRate  = 0.5  // Pelco range: 90° per second
+	tiltRate = 0.5  // Pelco range: 45° per second
+	zoomRate = 0.25 // wide to tele in 4 seconds
+)
+
+// State of the simulated camera, positions are normalized like camera.Position
+// (pan/tilt -1..1 = left/down..right/up, zoom 0..1 = wide..tele)
+type State struct {
+	Pan    float64
+	Tilt   float64
+	Zoom   float64
+	Power  bool
+	Moving bool // an axis is moving
+}
+
+func (s State) String() string {
+	return fmt.Sprintf("pan %+.2f tilt %+.2f zoom %.2f power %v moving %v", s.Pan, s.Tilt, s.Zoom, s.Power, s.Moving)
+}
+
+// axis moves with a velocity (drive) or to a target position with highest rate (absolute move, preset)
+type axis struct {
+	pos      float64
+	min, max float64
+	rate     float64 // range per second at highest speed
+	velocity float64 // range per second while driving
+	target   float64
+	moving   bool // moving to target
+}
+
+// drive with speed -1..1 of the highest rate (0 stops)
+func (a *axis) drive(speed float64) {
+	a.moving = false
+	a.velocity = math.Max(-1, math.Min(1, speed)) * a.rate
+}
+
+// move to position with highest rate
+func (a *axis) moveTo(pos float64) {
+	a.velocity = 0
+	a.target = math.Max(a.min, math.Min(a.max, pos))
+	a.moving = true
+}
+
+// integrate position over dt seconds (limits stop the axis)
+func (a *axis) advance(dt float64) {
+	if a.moving {
+		step := a.rate * dt
+		if math.Abs(a.target-a.pos) <= step {
+			a.pos = a.target
+			a.moving = false
+		} else {
+			a.pos += math.Copysign(step, a.target-a.pos)
+		}
+		return
+	}
+	a.pos += a.velocity * dt
+	if a.pos <= a.min || a.pos >= a.max {
+		a.pos = math.Max(a.min, math.Min(a.max, a.pos))
+		a.velocity = 0
+	}
+}
+
+func (a *axis) active() bool {
+	return a.moving || a.velocity != 0
+}
+
+// Camera is a simulated camera of a protocol with a device address
+type Camera struct {
+	protocol Protocol
+	address  byte // device number 1..255 (Pelco-P address + 1 like the driver), VISCA 1..7
+	split    bufio.SplitFunc
+	// Tenveo reads the zoom speed of pan speed in Pelco-D moves like the Tenveo NV10U (default of TenveoNV10U, see driver)
+	Tenveo bool
+	// off-spec responses of some cameras which the drivers have to cope with
+	Echo         bool // Pelco: commands without response are echoed
+	BadChecksum  bool // Pelco: responses have a wrong checksum
+	NoCompletion bool // VISCA: commands are acknowledged without completion
+
+	mu        sync.Mutex
+	pan       axis
+	tilt      axis
+	zoom      axis
+	zoomSpeed byte // Pelco "set zoom speed" 0..3
+	power     bool // on or standby (moves are ignored in standby)
+	presets   map[byte]State
+	updated   time.Time // positions are integrated until
+	pending   []byte    // received data which is not a complete frame yet
+	now       func() time.Time
+}
+
+// New creates a simulated camera at home position (pan/tilt 0, zoom wide), power on
+func New(protocol Protocol, address byte) (*Camera, error) {
+	c := &Camera{
+		protocol:  protocol,
+		address:   address,
+		Tenveo:    protocol == TenveoNV10U,
+		pan:       axis{min: -1, max: 1, rate: panRate},
+		tilt:      axis{min: -1, max: 1, rate: tiltRate},
+		zoom:      axis{min: 0, max: 1, rate: zoomRate},
+		zoomSpeed: pelcod.MaxZoomSpeed,
+		power:     true,
+		presets:   map[byte]State{},
+		now:       time.Now,
+	}
+	switch protocol {
+	case PelcoD, TenveoNV10U:
+		c.split = pelcod.ScanFrames
+	case PelcoP:
+		c.split = pelcop.ScanFrames
+	case Visca:
+		c.split = visca.ScanMessages
+		if address < 1 || address > visca.MaxAddress {
+			return nil, fmt.Errorf("simulator: invalid VISCA camera address %d (1..%d)", address, visca.MaxAddress)
+		}
+	default:
+		return nil, fmt.Errorf("simulator: unknown protocol '%v' (%v, %v, %v, %v)", protocol, PelcoD, TenveoNV10U, PelcoP, Visca)
+	}
+	if address < 1 {
+		return nil, fmt.Errorf("simulator: invalid camera address %d", address)
+	}
+	c.updated = c.now()
+	return c, nil
+}
+
+// Transport returns a memory transport to the simulated camera (see camera.Options)
+func (c *Camera) Transport() camera.Transport {
+	return camera.NewMemoryTransport(fmt.Sprintf("simulator/%v/%d", c.protocol, c.address), c.Handle)
+}
+
+// State returns the current position
+func (c *Camera) State() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.update()
+	return c.state()
+}
+
+// Handle decodes the frames of data (partial frames are completed by the next call) and returns the responses
+func (c *Camera) Handle(data []byte) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.update()
+	c.pending = append(c.pending, data...)
+	var responses []byte
+	for len(c.pending) > 0 {
+		advance, frame, err := c.split(c.pending, false)
+		if err != nil {
+			log.Printf("Simulator: %v\n", err)
+			c.pending = nil
+			break
+		}
+		if advance == 0 {
+			break
+		}
+		c.pending = c.pending[advance:]
+		if frame == nil {
+			continue
+		}
+		var r []byte
+		if c.protocol == Visca {
+			r = c.visca(frame)
+		} else {
+			r = c.pelco(frame)
+		}
+		log.Printf("Simulator %s: %s -> %s (%v)\n", c.protocol, hex.EncodeToString(frame), hex.EncodeToString(r), c.state())
+		responses = append(responses, r...)
+	}
+	return responses
+}
+
+// integrate the motion since the last update
+func (c *Camera) update() {
+	now := c.now()
+	dt := now.Sub(c.updated).Seconds()
+	c.updated = now
+	if dt <= 0 {
+		return
+	}
+	c.pan.advance(dt)
+	c.tilt.advance(dt)
+	c.zoom.advance(dt)
+}
+
+func (c *Camera) state() State {
+	return State{
+		Pan:    c.pan.pos,
+		Tilt:   c.tilt.pos,
+		Zoom:   c.zoom.pos,
+		Power:  c.power,
+		Moving: c.pan.active() || c.tilt.active() || c.zoom.active(),
+	}
+}
+
+// drive pan/tilt/zoom with speed -1..1 (moves are ignored in standby)
+func (c *Camera) drive(pan, tilt, zoom float64) {
+	if !c.power {
+		return
+	}
+	c.pan.drive(pan)
+	c.tilt.drive(tilt)
+	c.zoom.drive(zoom)
+}
+
+// move to position (absolute move or preset)
+func (c *Camera) moveTo(s State) {
+	if !c.power {
+		return
+	}
+	c.pan.moveTo(s.Pan)
+	c.tilt.moveTo(s.Tilt)
+	c.zoom.moveTo(s.Zoom)
+}
+
+// drive single axis with speed -1..1 (VISCA pan/tilt and zoom are separate commands)
+func (c *Camera) driveAxis(a *axis, speed float64) {
+	if c.power {
+		a.drive(speed)
+	}
+}
+
+// move single axis to position (absolute move of one axis)
+func (c *Camera) moveAxis(a *axis, pos float64) {
+	if c.power {
+		a.moveTo(pos)
+	}
+}
+
+func (c *Camera) savePreset(preset byte) {
+	c.presets[preset] = c.state()
+}
+
+func (c *Camera) recallPreset(preset byte) {
+	if s, ok := c.presets[preset]; ok {
+		c.moveTo(s)
+	}
+}
+
+// reset stops and moves to home position
+func (c *Camera) reset() {
+	c.drive(0, 0, 0)
+	c.pan.moveTo(0)
+	c.tilt.moveTo(0)
+}
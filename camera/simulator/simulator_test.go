@@ -0,0 +1,123 @@
+package simulator
+
+import (
+	"camcontrol/camera"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock of the simulated camera advanced by the test
+type clock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *clock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+// driver of protocol on a simulated camera with test clock
+func newCamera(t *testing.T, protocol Protocol, address byte) (camera.Camera, *Camera, *clock) {
+	t.Helper()
+	sim, err := New(protocol, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := &clock{t: sim.updated}
+	sim.now = clk.now
+	cam, err := camera.New(string(protocol), camera.Options{Transport: sim.Transport(), Address: address})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cam.Close)
+	return cam, sim, clk
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+// every driver moves the simulated camera, stores and recalls presets and reads the position
+func TestDrivers(t *testing.T) {
+	tests := []struct {
+		protocol Protocol
+		address  byte
+	}{
+		{PelcoD, 1},
+		{TenveoNV10U, 2},
+		{PelcoP, 3},
+		{Visca, 1},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.protocol), func(t *testing.T) {
+			cam, sim, clk := newCamera(t, tt.protocol, tt.address)
+			check := func(step string, pan, tilt, zoom float64) {
+				t.Helper()
+				if s := sim.State(); !near(s.Pan, pan) || !near(s.Tilt, tilt) || !near(s.Zoom, zoom) || s.Moving {
+					t.Errorf("%v: state %v, want pan %+.2f tilt %+.2f zoom %.2f", step, s, pan, tilt, zoom)
+				}
+			}
+
+			// drive with highest speed for one second
+			if err := cam.Right(1); err != nil {
+				t.Fatal(err)
+			}
+			clk.advance(time.Second)
+			if err := cam.PtStop(); err != nil {
+				t.Fatal(err)
+			}
+			if err := cam.ZoomIn(0x3f); err != nil {
+				t.Fatal(err)
+			}
+			clk.advance(time.Second)
+			if err := cam.ZoomStop(); err != nil {
+				t.Fatal(err)
+			}
+			check("drive", panRate, 0, zoomRate)
+
+			// preset is recalled with highest rate
+			if err := cam.PresetSave(5); err != nil {
+				t.Fatal(err)
+			}
+			if err := cam.Down(1); err != nil {
+				t.Fatal(err)
+			}
+			clk.advance(time.Second)
+			if err := cam.PtStop(); err != nil {
+				t.Fatal(err)
+			}
+			check("down", panRate, -tiltRate, zoomRate)
+			if err := cam.PresetSelect(5); err != nil {
+				t.Fatal(err)
+			}
+			clk.advance(2 * time.Second)
+			check("preset", panRate, 0, zoomRate)
+
+			if !cam.Capabilities().PositionQuery {
+				return
+			}
+			if err := cam.AbsoluteMove(-0.5, 0.25, 0.75); err != nil {
+				t.Fatal(err)
+			}
+			clk.advance(5 * time.Second)
+			check("absolute move", -0.5, 0.25, 0.75)
+			p, err := cam.Position()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !near(p.Pan, -0.5) || !near(p.Tilt, 0.25) || !near(p.Zoom, 0.75) {
+				t.Errorf("position %+v, want pan -0.50 tilt +0.25 zoom 0.75", p)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package simulator
+
+import (
+	"camcontrol/camera/internal/position"
+	"camcontrol/camera/visca"
+	"math"
+)
+
+// VISCA: ACK and completion of commands, inquiries of pan/tilt, zoom, focus and power
+// (pan/tilt and zoom units like the driver)
+
+// decode message and return the replies (nil = message to other camera)
+func (c *Camera) visca(msg []byte) []byte {
+	if len(msg) < 3 {
+		return nil
+	}
+	payload := msg[1 : len(msg)-1]
+	if msg[0] == 0x80|visca.Broadcast {
+		switch {
+		case len(payload) == 2 && payload[0] == 0x30 && payload[1] == 0x01:
+			// address set: the next camera of the daisy chain gets the following address
+			return []byte{0x80 | visca.Broadcast, 0x30, c.address + 1, visca.Terminator}
+		case len(payload) == 3 && payload[0] == 0x01 && payload[1] == 0x00 && payload[2] == 0x01:
+			return []byte{0x80 | visca.Broadcast, 0x01, 0x00, 0x01, visca.Terminator}
+		}
+		return nil
+	}
+	if msg[0] != 0x80|c.address {
+		return nil
+	}
+	header := 0x80 | (c.address+visca.Broadcast)<<4
+	if len(payload) < 1 {
+		return []byte{header, byte(visca.Error), byte(visca.ErrMessageLength), visca.Terminator}
+	}
+	switch payload[0] {
+	case 0x01:
+		if len(payload) == 3 && payload[1] == 0x00 && payload[2] == 0x01 {
+			// IF clear of this camera
+			return []byte{header, byte(visca.Completion), visca.Terminator}
+		}
+		if !c.viscaCommand(payload[1:]) {
+			return []byte{header, byte(visca.Error), byte(visca.ErrSyntax), visca.Terminator}
+		}
+		if c.NoCompletion {
+			return []byte{header, byte(visca.Ack) | 1, visca.Terminator}
+		}
+		return []byte{header, byte(visca.Ack) | 1, visca.Terminator, header, byte(visca.Completion) | 1, visca.Terminator}
+	case 0x09:
+		data := c.viscaInquiry(payload[1:])
+		if data == nil {
+			return []byte{header, byte(visca.Error), byte(visca.ErrSyntax), visca.Terminator}
+		}
+		return append(append([]byte{header, byte(visca.Completion)}, data...), visca.Terminator)
+	}
+	return []byte{header, byte(visca.Error), byte(visca.ErrSyntax), visca.Terminator}
+}
+
+// execute command (without 0x01), false = syntax error
+func (c *Camera) viscaCommand(cmd []byte) bool {
+	if len(cmd) < 2 {
+		return false
+	}
+	switch {
+	case cmd[0] == 0x06 && cmd[1] == 0x01 && len(cmd) == 6:
+		c.driveAxis(&c.pan, viscaDirection(cmd[4], 0x02, 0x01)*float64(cmd[2])/visca.MaxPanSpeed)
+		c.driveAxis(&c.tilt, viscaDirection(cmd[5], 0x01, 0x02)*float64(cmd[3])/visca.MaxTiltSpeed)
+	case cmd[0] == 0x06 && cmd[1] == 0x02 && len(cmd) == 12:
+		pan, tilt := viscaWord(cmd[4:8]), viscaWord(cmd[8:12])
+		c.moveAxis(&c.pan, position.Normalize(float64(int16(pan)), -position.ViscaPanLimit, position.ViscaPanLimit))
+		c.moveAxis(&c.tilt, position.Normalize(float64(int16(tilt)), position.ViscaTiltDownLimit, position.ViscaTiltUpLimit))
+	case cmd[0] == 0x06 && (cmd[1] == 0x04 || cmd[1] == 0x05):
+		// home, reset
+		c.reset()
+	case cmd[0] == 0x04 && cmd[1] == 0x07 && len(cmd) == 3:
+		speed := float64(cmd[2]&0x07+1) / (visca.MaxZoomSpeed + 1)
+		switch cmd[2] & 0xf0 {
+		case 0x20:
+			c.driveAxis(&c.zoom, speed)
+		case 0x30:
+			c.driveAxis(&c.zoom, -speed)
+		case 0x00:
+			switch cmd[2] {
+			case 0x02:
+				c.driveAxis(&c.zoom, 1)
+			case 0x03:
+				c.driveAxis(&c.zoom, -1)
+			default:
+				c.driveAxis(&c.zoom, 0)
+			}
+		}
+	case cmd[0] == 0x04 && cmd[1] == 0x47 && len(cmd) == 6:
+		c.moveAxis(&c.zoom, float64(viscaWord(cmd[2:6]))/position.ViscaZoomTeleLimit)
+	case cmd[0] == 0x04 && cmd[1] == 0x3f && len(cmd) == 4:
+		switch cmd[2] {
+		case 0x00:
+			delete(c.presets, cmd[3])
+		case 0x01:
+			c.savePreset(cmd[3])
+		case 0x02:
+			c.recallPreset(cmd[3])
+		default:
+			return false
+		}
+	case cmd[0] == 0x04 && cmd[1] == 0x00 && len(cmd) == 3:
+		c.power = cmd[2] == 0x02
+		if !c.power {
+			c.drive(0, 0, 0)
+		}
+	case cmd[0] == 0x04 || cmd[0] == 0x06:
+		// focus, exposure, white balance...: accepted without effect
+	default:
+		return false
+	}
+	return true
+}
+
+// data of inquiry (without 0x09), nil = syntax error
+func (c *Camera) viscaInquiry(inq []byte) []byte {
+	if len(inq) != 2 {
+		return nil
+	}
+	switch {
+	case inq[0] == 0x06 && inq[1] == 0x12:
+		pan := int16(math.Round(position.Denormalize(c.pan.pos, -position.ViscaPanLimit, position.ViscaPanLimit)))
+		tilt := int16(math.Round(position.Denormalize(c.tilt.pos, position.ViscaTiltDownLimit, position.ViscaTiltUpLimit)))
+		return append(viscaNibbles(uint16(pan)), viscaNibbles(uint16(tilt))...)
+	case inq[0] == 0x04 && inq[1] == 0x47:
+		return viscaNibbles(uint16(math.Round(c.zoom.pos * position.ViscaZoomTeleLimit)))
+	case inq[0] == 0x04 && inq[1] == 0x48:
+		return viscaNibbles(0)
+	case inq[0] == 0x04 && inq[1] == 0x38:
+		return []byte{0x02} // auto focus
+	case inq[0] == 0x04 && inq[1] == 0x00:
+		if c.power {
+			return []byte{0x02}
+		}
+		return []byte{0x03}
+	}
+	return nil
+}
+
+// direction of drive byte: 1, -1 or 0 (stop)
+func viscaDirection(b, positive, negative byte) float64 {
+	switch b {
+	case positive:
+		return 1
+	case negative:
+		return -1
+	}
+	return 0
+}
+
+// split 16 bit value into 4 nibbles (0p 0q 0r 0s)
+func viscaNibbles(v uint16) []byte {
+	return []byte{byte(v>>12) & 0x0f, byte(v>>8) & 0x0f, byte(v>>4) & 0x0f, byte(v) & 0x0f}
+}
+
+// join 4 nibbles to 16 bit value
+func viscaWord(b []byte) uint16 {
+	var v uint16
+	for _, n := range b[:4] {
+		v = v<<4 | uint16(n&0x0f)
+	}
+	return v
+}
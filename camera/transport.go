@@ -0,0 +1,353 @@
+package camera
+
+import (
+	"bufio"
+	"camcontrol/device"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transport carries the bytes of a protocol driver to the camera, e.g. serial port, TCP, UDP or memory.
+// The protocol (frames, addresses, responses) is handled by the driver, therefore every protocol runs on every transport,
+// e.g. Pelco-D on an RS-485-to-Ethernet converter (see New with Options.Transport).
+type Transport interface {
+	// Open connects the transport, it is called again to reconnect after a failure (open connection is closed first)
+	Open() error
+	// Read returns received data, it must return with an error when the transport is closed
+	// (0 bytes or io.EOF = no data within a short time, e.g. inter character timeout of serial port)
+	Read(p []byte) (int, error)
+	Write(p []byte) (int, error)
+	Close() error
+	// Address identifies the camera connection, cameras on one bus with the same address share the transport
+	Address() string
+	// String describes the transport with its parameters (logs, shared transports must be equal)
+	String() string
+}
+
+// removableTransport is a transport of a device which may be removed (USB adapter), the link opens it again when it is plugged in
+type removableTransport interface {
+	Transport
+	// present returns if the opened device is still present and if any device of the transport is available
+	present() (opened, available bool)
+}
+
+// portReader is a transport which returns the reader of its opened port or connection (nil = closed),
+// therefore the frame reader of a closed port never reads data of the port opened next
+type portReader interface {
+	reader() io.Reader
+}
+
+// reader of the opened port of transport t (the transport itself if it has no port reader)
+func readerOf(t Transport) io.Reader {
+	if p, ok := t.(portReader); ok {
+		if r := p.reader(); r != nil {
+			return r
+		}
+	}
+	return t
+}
+
+// ParseTransport returns the transport of a URL, e.g. "tcp://192.168.0.10:4001" (raw socket of serial server),
+// "rfc2217://192.168.0.10:4001" (serial server with Telnet COM port control) or "udp://192.168.0.10:52381"
+// ("" or "serial" = serial port of the camera, nil is returned)
+func ParseTransport(s string) (Transport, error) {
+	if s == "" || strings.EqualFold(s, "serial") {
+		return nil, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transport '%v': %v", s, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid transport '%v': no host given", s)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "tcp":
+		return NewTCPTransport(u.Host), nil
+	case "rfc2217", "telnet":
+		return NewRFC2217Transport(u.Host), nil
+	case "udp":
+		return NewUDPTransport(u.Host), nil
+	}
+	return nil, fmt.Errorf("invalid transport '%v' (serial, tcp://host:port, rfc2217://host:port, udp://host:port)", s)
+}
+
+// transportLink sends the messages of a driver on a transport and reads the response frames until their deadline,
+// the transport is reconnected on failure (and in the background if it is removable, see watch)
+type transportLink struct {
+	transport   Transport
+	opened      bool            // transport is open
+	split       bufio.SplitFunc // frames of the protocol
+	reader      *frameReader    // frames read from transport
+	lastWrite   time.Time       // end of last frame (for frame delay)
+	frameDelay  time.Duration   // minimum pause between frames
+	readTimeout time.Duration   // minimum wait for responses
+	simulation  bool            // transport is not opened, frames are logged only
+	refs        int             // cameras using the link (see links)
+
+	initMu      sync.Mutex // protocol init is running (see initOnce)
+	initialized bool       // protocol init of the first camera succeeded
+
+	mu        sync.Mutex             // link is used by UI and watcher
+	watcher   *device.Watcher        // nil = no background reconnect
+	connected bool                   // last notified state
+	notify    []func(connected bool) // called on change of connection state (every camera of the link)
+}
+
+// links in use by transport address, cameras on one bus (e.g. RS-485 with Pelco-D addresses or VISCA daisy chain) share the link
+var (
+	links   = map[string]*transportLink{}
+	linksMu sync.Mutex
+)
+
+const (
+	transportReconnectRetries = 3
+	transportReconnectDelay   = 500 * time.Millisecond // device node may be accessible after a short time only
+)
+
+// link of transport t, split returns the response frames of the protocol.
+// A transport in use is shared if its parameters and the protocol are equal.
+func newTransportLink(t Transport, split bufio.SplitFunc, frameDelay, readTimeout time.Duration) (*transportLink, error) {
+	log.Printf("Transport: %v\n", t)
+	linksMu.Lock()
+	defer linksMu.Unlock()
+	key := t.Address()
+	if l, ok := links[key]; ok {
+		if l.transport.String() != t.String() || reflect.ValueOf(l.split).Pointer() != reflect.ValueOf(split).Pointer() {
+			return nil, fmt.Errorf("transport '%v' is already used with other parameters or protocol", key)
+		}
+		l.refs++
+		return l, nil
+	}
+	l := &transportLink{
+		transport:   t,
+		split:       split,
+		frameDelay:  frameDelay,
+		readTimeout: readTimeout,
+		refs:        1,
+	}
+	links[key] = l
+	return l, nil
+}
+
+// run protocol init of the first camera (failed init is run again by the next camera)
+func (l *transportLink) initOnce(init func() error) error {
+	l.initMu.Lock()
+	defer l.initMu.Unlock()
+	if l.initialized {
+		return nil
+	}
+	if err := init(); err != nil {
+		return err
+	}
+	l.initialized = true
+	return nil
+}
+
+// open transport (shared transport is opened once)
+func (l *transportLink) connect() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.opened {
+		return nil
+	}
+	return l.open()
+}
+
+func (l *transportLink) open() error {
+	if l.simulation {
+		return nil
+	}
+	l.closeTransport()
+	err := l.transport.Open()
+	if err == nil {
+		l.opened = true
+		l.reader = newFrameReader(readerOf(l.transport), l.split)
+	}
+	l.setConnected(err == nil)
+	return err
+}
+
+// close transport if it is not used by other cameras
+func (l *transportLink) Close() {
+	linksMu.Lock()
+	l.refs--
+	if l.refs > 0 {
+		linksMu.Unlock()
+		return
+	}
+	delete(links, l.transport.Address())
+	linksMu.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.watcher != nil {
+		l.watcher.Close()
+		l.watcher = nil
+	}
+	l.notify = nil
+	l.closeTransport()
+}
+
+func (l *transportLink) closeTransport() {
+	if l.opened {
+		// the reader returns from a blocked Read when the port is closed
+		l.reader.close()
+		_ = l.transport.Close()
+		l.reader.wait()
+		l.opened = false
+	}
+}
+
+func (l *transportLink) setConnected(connected bool) {
+	if connected != l.connected {
+		l.connected = connected
+		for _, notify := range l.notify {
+			notify(connected)
+		}
+	}
+}
+
+// watch connection state, removable transports (USB adapters) are closed after removal
+// and opened again in the background when plugged in
+func (l *transportLink) watch(notify func(connected bool)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.notify = append(l.notify, notify)
+	if l.simulation {
+		notify(true)
+		return nil
+	}
+	if _, ok := l.transport.(removableTransport); !ok || l.watcher != nil {
+		// state changes on open and failure (watcher of shared link is running already)
+		notify(l.connected)
+		return nil
+	}
+	w, err := device.NewWatcher()
+	if err != nil {
+		return err
+	}
+	l.watcher = w
+	notify(l.connected)
+	go func() {
+		for range w.C {
+			for i := 0; i < transportReconnectRetries && !l.check(); i++ {
+				time.Sleep(transportReconnectDelay)
+			}
+		}
+	}()
+	return nil
+}
+
+// check removable transport after change of the devices, false = reconnect failed
+func (l *transportLink) check() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.watcher == nil {
+		return true
+	}
+	opened, available := l.transport.(removableTransport).present()
+	if l.opened {
+		if opened {
+			return true
+		}
+		log.Printf("Transport %v removed\n", l.transport.Address())
+		l.closeTransport()
+		l.setConnected(false)
+	}
+	if !available {
+		return true
+	}
+	if err := l.open(); err != nil {
+		log.Printf("Reconnect failed: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// write message and return the response frames of the camera
+func (l *transportLink) send(msg []byte, r response) ([][]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.opened {
+		err := l.open()
+		if err != nil {
+			return nil, fmt.Errorf("failed writing to port: %v", err)
+		}
+	}
+	if !l.opened {
+		log.Printf("Simulate request: %s\n", hex.EncodeToString(msg))
+		return nil, nil
+	}
+	if data := l.reader.discard(); len(data) > 0 {
+		log.Printf("Discard : %s\n", hex.EncodeToString(data))
+	}
+	if err := l.reader.failed(); err != nil {
+		// e.g. connection dropped by serial server
+		log.Printf("Transport failed: %v, reconnect...", err)
+		if err := l.open(); err != nil {
+			return nil, fmt.Errorf("failed writing to port: %v", err)
+		}
+	}
+	n, err := l.write(msg)
+	if err != nil {
+		log.Printf("Failed writing to port: %v, try reconnect...", err)
+		err := l.open()
+		if err != nil {
+			return nil, fmt.Errorf("failed writing to port: %v", err)
+		}
+		n, err = l.write(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed writing to port: %v", err)
+		}
+	}
+	log.Printf("Wrote %d bytes: %s\n", n, hex.EncodeToString(msg))
+	if r.timeout == 0 {
+		return nil, nil
+	}
+	return l.readResponse(msg, r)
+}
+
+// write frame after the frame delay of the camera
+func (l *transportLink) write(msg []byte) (int, error) {
+	if wait := l.frameDelay - time.Since(l.lastWrite); wait > 0 {
+		time.Sleep(wait)
+	}
+	n, err := l.transport.Write(msg)
+	l.lastWrite = time.Now()
+	return n, err
+}
+
+// read frames until the response is complete or the deadline passed (read timeout extends the deadline)
+func (l *transportLink) readResponse(msg []byte, r response) ([][]byte, error) {
+	timeout := r.timeout
+	if timeout < l.readTimeout {
+		timeout = l.readTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	var frames [][]byte
+	for {
+		frame, err := l.reader.next(deadline)
+		if err == errFrameTimeout {
+			return frames, &TimeoutError{Request: msg, Wait: timeout}
+		}
+		if err != nil {
+			log.Printf("Failed reading from port: %v\n", err)
+			l.closeTransport()
+			l.setConnected(false)
+			return frames, fmt.Errorf("failed reading from port: %v", err)
+		}
+		log.Printf("Response : %s\n", hex.EncodeToString(frame))
+		frames = append(frames, frame)
+		if r.done == nil || r.done(frame) {
+			return frames, nil
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package camera
+
+import (
+	"bytes"
+	"camcontrol/camera/pelcod"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseTransport(t *testing.T) {
+	tests := []struct {
+		transport string
+		address   string // "" = serial port (nil) or error
+		err       bool
+	}{
+		{"", "", false},
+		{"serial", "", false},
+		{"Serial", "", false},
+		{"tcp://192.168.0.10:4001", "tcp://192.168.0.10:4001", false},
+		{"TCP://cam:4001", "tcp://cam:4001", false},
+		{"udp://192.168.0.10:52381", "udp://192.168.0.10:52381", false},
+		{"rfc2217://192.168.0.10:4001", "rfc2217://192.168.0.10:4001", false},
+		{"telnet://192.168.0.10:23", "rfc2217://192.168.0.10:23", false},
+		// the simulator is attached by the application (virtual camera of the protocol and address)
+		{"simulator", "", true},
+		{"tcp://", "", true},
+		{"tcp:192.168.0.10", "", true},
+		{"http://192.168.0.10", "", true},
+		{"tcp://[::1:4001", "", true},
+		{"192.168.0.10:4001", "", true},
+	}
+	for _, tt := range tests {
+		tr, err := ParseTransport(tt.transport)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseTransport(%q) error %v, want error %v", tt.transport, err, tt.err)
+			continue
+		}
+		address := ""
+		if tr != nil {
+			address = tr.Address()
+		}
+		if address != tt.address {
+			t.Errorf("ParseTransport(%q) = %v, want %v", tt.transport, address, tt.address)
+		}
+	}
+}
+
+// connection closed by the serial server is opened again by the next send
+func TestTransportReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	var (
+		mu          sync.Mutex
+		connections int
+	)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			connections++
+			mu.Unlock()
+			// echo frames, the first connection is closed after one frame
+			go func(conn net.Conn, first bool) {
+				defer conn.Close()
+				frame := make([]byte, 7)
+				for {
+					if _, err := io.ReadFull(conn, frame); err != nil {
+						return
+					}
+					_, _ = conn.Write(frame)
+					if first {
+						return
+					}
+				}
+			}(conn, connections == 1)
+		}
+	}()
+
+	l, err := newTransportLink(NewTCPTransport(ln.Addr().String()), pelcod.ScanFrames, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	msg := pelcod.Encode(1, pelcod.QueryPan{}).Bytes()
+	send := func(step string) {
+		t.Helper()
+		frames, err := l.send(msg, response{timeout: time.Second})
+		if err != nil || len(frames) != 1 || !bytes.Equal(frames[0], msg) {
+			t.Fatalf("%v: %x, %v", step, frames, err)
+		}
+	}
+	send("first connection")
+	// wait for the end of stream of the closed connection
+	for deadline := time.Now().Add(time.Second); len(l.reader.chunks) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("connection not closed by server")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	send("reconnect")
+	send("second connection")
+	mu.Lock()
+	defer mu.Unlock()
+	if connections != 2 {
+		t.Errorf("%d connections, want 2", connections)
+	}
+}
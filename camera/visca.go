@@ -0,0 +1,452 @@
+package camera
+
+import (
+	"camcontrol/camera/internal/position"
+	"camcontrol/camera/visca"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// Using protocol VISCA (see package visca), e.g. Sony or compatible cameras on RS-232/RS-422 or IP (see viscaip.go)
+
+const viscaTimeout = 500 * time.Millisecond // deadline of ACK and completion
+
+type viscaCamera struct {
+	link
+	address byte // camera address 1..7 (assigned by address set in daisy chain order)
+
+	mu      sync.Mutex    // sockets are used by UI and position readout
+	sockets map[byte]bool // sockets of accepted commands with outstanding completion (see viscaAccepted)
+}
+
+func init() {
+	Register(Driver{
+		Name:        "visca",
+		Description: "VISCA camera on RS-232/RS-422 (9600 baud)",
+		Schema:      Schema{Serial: true, MinAddress: 1, MaxAddress: visca.MaxAddress},
+		New: func(o Options) (Camera, error) {
+			l, err := o.link(9600, visca.ScanMessages)
+			if err != nil {
+				return nil, err
+			}
+			return newVisca(l, o.Address)
+		},
+	})
+}
+
+// create camera object for VISCA camera (use selected device or search for first recognized device like NewTenveoNV10U)
+func NewVisca(port SerialConfig, address byte) (*viscaCamera, error) {
+	if address < 1 || address > visca.MaxAddress {
+		return nil, fmt.Errorf("invalid VISCA camera address %d (1..%d)", address, visca.MaxAddress)
+	}
+	if err := port.Validate(); err != nil {
+		return nil, err
+	}
+	l, err := newSerialLink(port, 9600, visca.ScanMessages)
+	if err != nil {
+		return nil, err
+	}
+	return newVisca(l, address)
+}
+
+// VISCA camera on link l (serial port or other transport, see viscaip.go for VISCA over IP)
+func newVisca(l link, address byte) (*viscaCamera, error) {
+	c := viscaCamera{
+		link:    l,
+		address: address,
+		sockets: map[byte]bool{},
+	}
+	err := c.connect()
+	if err == nil {
+		err = initLink(l, c.init)
+	}
+	return &c, err
+}
+
+// assign camera addresses and clear command buffers (serial daisy chain only, once for all cameras of the link)
+func (c *viscaCamera) init() error {
+	if _, err := c.execute(visca.Broadcast, visca.AddressSet(), viscaCompleted); err != nil {
+		return err
+	}
+	_, err := c.execute(visca.Broadcast, visca.IFClear(), viscaCompleted)
+	return err
+}
+
+func (c *viscaCamera) Watch(notify func(connected bool)) error {
+	return watchLink(c.link, notify)
+}
+
+// presets are limited to 7 bits (many cameras support less presets, e.g. 6 or 16)
+func (c *viscaCamera) Capabilities() Capabilities {
+	return Capabilities{
+		VariableSpeed: true,
+		Diagonal:      true,
+		Focus:         true,
+		OnePushFocus:  true,
+		Iris:          true,
+		Gain:          true,
+		Backlight:     true,
+		AbsoluteMove:  true,
+		PositionQuery: true,
+		Power:         true,
+		MaxPresets:    0x7f,
+
+		ExposureModes: []ExposureMode{ExposureAuto, ExposureManual, ExposureShutterPriority, ExposureIrisPriority},
+		WhiteBalanceModes: []WhiteBalanceMode{WhiteBalanceAuto, WhiteBalanceIndoor, WhiteBalanceOutdoor,
+			WhiteBalanceOnePush, WhiteBalanceManual},
+	}
+}
+
+func (c *viscaCamera) Up(speed float64) error {
+	log.Printf("Cam up %.2f\n", speed)
+	return c.sendCommand(visca.PanTiltDrive(visca.Stop, visca.TiltUp, 1, scaleSpeed(speed, 1, visca.MaxTiltSpeed)))
+}
+
+func (c *viscaCamera) Down(speed float64) error {
+	log.Printf("Cam down %.2f\n", speed)
+	return c.sendCommand(visca.PanTiltDrive(visca.Stop, visca.TiltDown, 1, scaleSpeed(speed, 1, visca.MaxTiltSpeed)))
+}
+
+func (c *viscaCamera) Left(speed float64) error {
+	log.Printf("Cam left %.2f\n", speed)
+	return c.sendCommand(visca.PanTiltDrive(visca.PanLeft, visca.Stop, scaleSpeed(speed, 1, visca.MaxPanSpeed), 1))
+}
+
+func (c *viscaCamera) Right(speed float64) error {
+	log.Printf("Cam right %.2f\n", speed)
+	return c.sendCommand(visca.PanTiltDrive(visca.PanRight, visca.Stop, scaleSpeed(speed, 1, visca.MaxPanSpeed), 1))
+}
+
+func (c *viscaCamera) PtStop() error {
+	log.Println("Cam stop pt")
+	return c.sendCommand(visca.PanTiltDrive(visca.Stop, visca.Stop, 1, 1))
+}
+
+// VISCA has no combined pan/tilt/zoom command, therefore zoom is sent separately
+func (c *viscaCamera) Move(pan, tilt, zoom float64) error {
+	log.Printf("Cam move %.2f %.2f %.2f\n", pan, tilt, zoom)
+	err := c.sendCommand(visca.PanTiltDrive(visca.Direction(sign(pan)), visca.Direction(sign(tilt)),
+		scaleSpeed(math.Abs(pan), 1, visca.MaxPanSpeed), scaleSpeed(math.Abs(tilt), 1, visca.MaxTiltSpeed)))
+	if err != nil {
+		return err
+	}
+	speed := scaleSpeed(math.Abs(zoom), 0, visca.MaxZoomSpeed)
+	switch {
+	case zoom > 0:
+		return c.sendCommand(visca.ZoomTele(speed))
+	case zoom < 0:
+		return c.sendCommand(visca.ZoomWide(speed))
+	}
+	return c.sendCommand(visca.ZoomStop())
+}
+
+func (c *viscaCamera) ZoomIn(speed byte) error {
+	log.Println("Cam zoom in")
+	return c.sendCommand(visca.ZoomTele(zoomSpeed(speed)))
+}
+
+func (c *viscaCamera) ZoomOut(speed byte) error {
+	log.Println("Cam zoom out")
+	return c.sendCommand(visca.ZoomWide(zoomSpeed(speed)))
+}
+
+func (c *viscaCamera) ZoomStop() error {
+	log.Println("Cam zoom stop")
+	return c.sendCommand(visca.ZoomStop())
+}
+
+func (c *viscaCamera) FocusNear(speed byte) error {
+	log.Println("Cam focus near")
+	return c.sendCommand(visca.FocusNear(focusSpeed(speed)))
+}
+
+func (c *viscaCamera) FocusFar(speed byte) error {
+	log.Println("Cam focus far")
+	return c.sendCommand(visca.FocusFar(focusSpeed(speed)))
+}
+
+func (c *viscaCamera) FocusStop() error {
+	log.Println("Cam focus stop")
+	return c.sendCommand(visca.FocusStop())
+}
+
+func (c *viscaCamera) FocusAuto() error {
+	log.Println("Cam focus auto")
+	return c.sendCommand(visca.FocusAuto())
+}
+
+func (c *viscaCamera) FocusManual() error {
+	log.Println("Cam focus manual")
+	return c.sendCommand(visca.FocusManual())
+}
+
+func (c *viscaCamera) FocusOnePush() error {
+	log.Println("Cam focus one push")
+	return c.sendCommand(visca.FocusOnePush())
+}
+
+// limit focus speed to VISCA range
+func focusSpeed(speed byte) byte {
+	if speed > visca.MaxFocusSpeed {
+		return visca.MaxFocusSpeed
+	}
+	return speed
+}
+
+// limit zoom speed to VISCA range
+func zoomSpeed(speed byte) byte {
+	if speed > visca.MaxZoomSpeed {
+		return visca.MaxZoomSpeed
+	}
+	return speed
+}
+
+func (c *viscaCamera) IrisOpen() error {
+	log.Println("Cam iris open")
+	return c.sendCommand(visca.IrisUp())
+}
+
+func (c *viscaCamera) IrisClose() error {
+	log.Println("Cam iris close")
+	return c.sendCommand(visca.IrisDown())
+}
+
+func (c *viscaCamera) GainUp() error {
+	log.Println("Cam gain up")
+	return c.sendCommand(visca.GainUp())
+}
+
+func (c *viscaCamera) GainDown() error {
+	log.Println("Cam gain down")
+	return c.sendCommand(visca.GainDown())
+}
+
+func (c *viscaCamera) Exposure(mode ExposureMode) error {
+	log.Printf("Cam exposure %v\n", mode)
+	ae, ok := map[ExposureMode]byte{
+		ExposureAuto:            visca.AEFullAuto,
+		ExposureManual:          visca.AEManual,
+		ExposureShutterPriority: visca.AEShutterPriority,
+		ExposureIrisPriority:    visca.AEIrisPriority,
+	}[mode]
+	if !ok {
+		return fmt.Errorf("exposure mode %v %w", mode, ErrNotSupported)
+	}
+	return c.sendCommand(visca.Exposure(ae))
+}
+
+func (c *viscaCamera) Backlight(on bool) error {
+	log.Printf("Cam backlight %v\n", on)
+	return c.sendCommand(visca.Backlight(on))
+}
+
+func (c *viscaCamera) WhiteBalance(mode WhiteBalanceMode) error {
+	log.Printf("Cam white balance %v\n", mode)
+	wb, ok := map[WhiteBalanceMode]byte{
+		WhiteBalanceAuto:    visca.WBAuto,
+		WhiteBalanceIndoor:  visca.WBIndoor,
+		WhiteBalanceOutdoor: visca.WBOutdoor,
+		WhiteBalanceOnePush: visca.WBOnePush,
+		WhiteBalanceManual:  visca.WBManual,
+	}[mode]
+	if !ok {
+		return fmt.Errorf("white balance mode %v %w", mode, ErrNotSupported)
+	}
+	return c.sendCommand(visca.WhiteBalance(wb))
+}
+
+func (c *viscaCamera) WhiteBalanceTrigger() error {
+	log.Println("Cam white balance trigger")
+	return c.sendCommand(visca.WhiteBalanceTrigger())
+}
+
+// completion is sent after movement, therefore the ACK is sufficient
+func (c *viscaCamera) PresetSelect(preset byte) error {
+	log.Printf("Cam preset select %d\n", preset)
+	_, err := c.execute(c.address, visca.MemoryRecall(preset), viscaAccepted)
+	return err
+}
+
+func (c *viscaCamera) PresetSave(preset byte) error {
+	log.Printf("Cam preset save %d\n", preset)
+	return c.sendCommand(visca.MemorySet(preset))
+}
+
+func (c *viscaCamera) PresetReset(preset byte) error {
+	log.Println("Cam preset reset")
+	return c.sendCommand(visca.MemoryReset(preset))
+}
+
+func (c *viscaCamera) Position() (p Position, err error) {
+	r, err := c.inquiry(visca.PanTiltPosInq())
+	if err != nil {
+		return p, err
+	}
+	pan, tilt, err := visca.PanTiltPosition(r)
+	if err != nil {
+		return p, err
+	}
+	if r, err = c.inquiry(visca.ZoomPosInq()); err != nil {
+		return p, err
+	}
+	zoom, err := visca.ZoomPosition(r)
+	if err != nil {
+		return p, err
+	}
+	p.RawPan, p.RawTilt, p.RawZoom = float64(pan), float64(tilt), float64(zoom)
+	p.Pan = position.Normalize(p.RawPan, -position.ViscaPanLimit, position.ViscaPanLimit)
+	p.Tilt = position.Normalize(p.RawTilt, position.ViscaTiltDownLimit, position.ViscaTiltUpLimit)
+	p.Zoom = math.Min(1, p.RawZoom/position.ViscaZoomTeleLimit)
+	return p, nil
+}
+
+// completion is sent after movement, therefore the ACK is sufficient
+func (c *viscaCamera) AbsoluteMove(pan, tilt, zoom float64) error {
+	log.Printf("Cam absolute move %.2f %.2f %.2f\n", pan, tilt, zoom)
+	p := int16(math.Round(position.Denormalize(pan, -position.ViscaPanLimit, position.ViscaPanLimit)))
+	t := int16(math.Round(position.Denormalize(tilt, position.ViscaTiltDownLimit, position.ViscaTiltUpLimit)))
+	_, err := c.execute(c.address, visca.PanTiltAbsolute(p, t, visca.MaxPanSpeed, visca.MaxTiltSpeed), viscaAccepted)
+	if err != nil {
+		return err
+	}
+	z := uint16(math.Round(math.Max(0, math.Min(1, zoom)) * position.ViscaZoomTeleLimit))
+	_, err = c.execute(c.address, visca.ZoomDirect(z), viscaAccepted)
+	return err
+}
+
+// switch camera on or to standby
+func (c *viscaCamera) Power(on bool) error {
+	log.Printf("Cam power %v\n", on)
+	return c.sendCommand(visca.Power(on))
+}
+
+// VISCA has no restart command (Pan-tiltDrive Reset only initializes pan/tilt)
+func (c *viscaCamera) Reset() error {
+	return ErrNotSupported
+}
+
+// read power state (true = on, false = standby)
+func (c *viscaCamera) PowerState() (bool, error) {
+	r, err := c.inquiry(visca.PowerInq())
+	if err != nil {
+		return false, err
+	}
+	return visca.PowerState(r)
+}
+
+// send command according protocol and wait for completion
+func (c *viscaCamera) sendCommand(cmd visca.Command) error {
+	_, err := c.execute(c.address, cmd, viscaCompleted)
+	return err
+}
+
+// send inquiry and return the completion containing the data
+func (c *viscaCamera) inquiry(cmd visca.Command) (visca.Reply, error) {
+	replies, err := c.execute(c.address, cmd, viscaInquired)
+	if err != nil {
+		return visca.Reply{}, err
+	}
+	for _, r := range replies {
+		if r.Type == visca.Completion {
+			return r, nil
+		}
+	}
+	return visca.Reply{}, fmt.Errorf("visca: no reply to inquiry %v", cmd)
+}
+
+// replies awaited by execute
+type viscaWait int
+
+const (
+	viscaCompleted viscaWait = iota // completion (or error) of the socket of the ACK
+	viscaAccepted                   // ACK, the completion is sent after movement and dropped later (see reply)
+	viscaInquired                   // completion of socket 0 with data
+)
+
+// send message and check replies of the camera (ACK, completion or error),
+// late completions of accepted commands are dropped
+func (c *viscaCamera) execute(address byte, cmd visca.Command, wait viscaWait) ([]visca.Reply, error) {
+	var replies []visca.Reply
+	var parseErr error
+	socket := -1 // socket of the ACK
+	_, err := c.send(visca.Encode(address, cmd), response{timeout: viscaTimeout, done: func(frame []byte) bool {
+		r, err := visca.ParseReply(frame)
+		if err != nil {
+			parseErr = err
+			return true
+		}
+		own, done := c.reply(r, address, wait, &socket)
+		if own {
+			replies = append(replies, r)
+		}
+		return done
+	}})
+	var timeout *TimeoutError
+	if errors.As(err, &timeout) && socket >= 0 {
+		// ACK without completion in time: command is executed, its completion is dropped later
+		log.Printf("visca: %v (command accepted)", err)
+		c.mu.Lock()
+		c.sockets[byte(socket)] = true
+		c.mu.Unlock()
+		err = nil
+	}
+	if err == nil {
+		err = parseErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range replies {
+		if err := r.Err(); err != nil {
+			return replies, err
+		}
+	}
+	return replies, nil
+}
+
+// reply r to a message of execute: own = reply of the message (not a late completion of an accepted command),
+// done = response is complete; socket is the socket of the ACK (-1 = no ACK yet)
+func (c *viscaCamera) reply(r visca.Reply, address byte, wait viscaWait, socket *int) (own, done bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if address == visca.Broadcast {
+		return true, r.Type != visca.Ack
+	}
+	if r.Address != address {
+		// reply of another camera of the daisy chain (e.g. late completion), its sockets are not ours
+		log.Printf("visca: drop reply of camera %d %v\n", r.Address, r)
+		return false, false
+	}
+	switch r.Type {
+	case visca.Ack:
+		// socket is free again, its completion is not outstanding
+		delete(c.sockets, r.Socket)
+		*socket = int(r.Socket)
+		if wait == viscaAccepted {
+			c.sockets[r.Socket] = true
+			return true, true
+		}
+		return true, false
+	case visca.Completion:
+		switch {
+		case wait == viscaInquired && r.Socket == 0 && len(r.Data) > 0:
+			return true, true
+		case wait == viscaCompleted && (int(r.Socket) == *socket || *socket < 0 && !c.sockets[r.Socket]):
+			return true, true
+		}
+	case visca.Error:
+		// error of syntax, buffer or the own socket (late errors of accepted commands are dropped)
+		if r.Socket == 0 || int(r.Socket) == *socket || *socket < 0 && !c.sockets[r.Socket] {
+			return true, true
+		}
+	default:
+		return true, true
+	}
+	log.Printf("visca: drop late reply %v\n", r)
+	delete(c.sockets, r.Socket)
+	return false, false
+}
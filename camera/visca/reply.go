@@ -0,0 +1,192 @@
+package visca
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+)
+
+// ReplyType is the high nibble of the second reply byte
+type ReplyType byte
+
+const (
+	Ack        ReplyType = 0x40 // command accepted (socket in low nibble)
+	Completion ReplyType = 0x50 // command executed, inquiry data follows
+	Error      ReplyType = 0x60 // command failed, error code follows
+
+	AddressSetReply ReplyType = 0x30 // broadcast address set reply, next free address follows
+	IFClearReply    ReplyType = 0x00 // broadcast IF clear reply
+)
+
+// ErrorCode of an error reply
+type ErrorCode byte
+
+const (
+	ErrMessageLength ErrorCode = 0x01
+	ErrSyntax        ErrorCode = 0x02
+	ErrBufferFull    ErrorCode = 0x03
+	ErrCanceled      ErrorCode = 0x04
+	ErrNoSocket      ErrorCode = 0x05
+	ErrNotExecutable ErrorCode = 0x41
+	ErrNoCode        ErrorCode = 0xff // not sent by camera, used for error replies without code
+)
+
+func (e ErrorCode) String() string {
+	switch e {
+	case ErrMessageLength:
+		return "message length error"
+	case ErrSyntax:
+		return "syntax error"
+	case ErrBufferFull:
+		return "command buffer full"
+	case ErrCanceled:
+		return "command canceled"
+	case ErrNoSocket:
+		return "no socket"
+	case ErrNotExecutable:
+		return "command not executable"
+	}
+	return fmt.Sprintf("unknown error %#02x", byte(e))
+}
+
+// ReplyError is returned by Reply.Err for error replies
+type ReplyError struct {
+	Address byte
+	Socket  byte
+	Code    ErrorCode
+}
+
+func (e *ReplyError) Error() string {
+	return fmt.Sprintf("visca: camera %d socket %d: %v", e.Address, e.Socket, e.Code)
+}
+
+// Reply of a camera
+type Reply struct {
+	Address byte // address of the replying camera (Broadcast for broadcast replies)
+	Type    ReplyType
+	Socket  byte
+	Data    []byte // data between type byte and terminator
+}
+
+// Err returns a *ReplyError for error replies, otherwise nil
+func (r Reply) Err() error {
+	if r.Type != Error {
+		return nil
+	}
+	code := ErrNoCode
+	if len(r.Data) > 0 {
+		code = ErrorCode(r.Data[0])
+	}
+	return &ReplyError{Address: r.Address, Socket: r.Socket, Code: code}
+}
+
+func (r Reply) String() string {
+	return fmt.Sprintf("camera %d type %#02x socket %d data %s", r.Address, byte(r.Type), r.Socket, hex.EncodeToString(r.Data))
+}
+
+// ParseReply parses a single reply including header and terminator
+func ParseReply(b []byte) (r Reply, err error) {
+	if len(b) < 3 || b[len(b)-1] != Terminator {
+		return r, fmt.Errorf("visca: invalid reply: %s", hex.EncodeToString(b))
+	}
+	switch {
+	case b[0] == 0x80|Broadcast:
+		r.Address = Broadcast
+	case b[0] >= 0x90 && b[0]&0x0f == 0:
+		r.Address = b[0]>>4 - Broadcast
+	default:
+		return r, fmt.Errorf("visca: invalid reply address: %s", hex.EncodeToString(b))
+	}
+	r.Type = ReplyType(b[1] & 0xf0)
+	r.Socket = b[1] & 0x0f
+	r.Data = append([]byte{}, b[2:len(b)-1]...)
+	return r, nil
+}
+
+// SplitReplies parses all replies of buf (e.g. ACK followed by completion)
+func SplitReplies(buf []byte) (replies []Reply, err error) {
+	for len(buf) > 0 {
+		end := bytes.IndexByte(buf, Terminator)
+		if end < 0 {
+			return replies, fmt.Errorf("visca: missing terminator: %s", hex.EncodeToString(buf))
+		}
+		r, err := ParseReply(buf[:end+1])
+		if err != nil {
+			return replies, err
+		}
+		replies = append(replies, r)
+		buf = buf[end+1:]
+	}
+	return replies, nil
+}
+
+// ScanMessages is a split function for bufio.Scanner returning the messages up to the terminator
+// (e.g. reading replies from a serial port)
+func ScanMessages(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if end := bytes.IndexByte(data, Terminator); end >= 0 {
+		return end + 1, data[:end+1], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), nil, fmt.Errorf("visca: missing terminator: %s", hex.EncodeToString(data))
+	}
+	return 0, nil, nil
+}
+
+// PanTiltPosition decodes the completion of PanTiltPosInq
+func PanTiltPosition(r Reply) (pan, tilt int16, err error) {
+	if err = r.expect(8); err != nil {
+		return
+	}
+	p, err := word(r.Data[0:4])
+	if err != nil {
+		return
+	}
+	t, err := word(r.Data[4:8])
+	return int16(p), int16(t), err
+}
+
+// ZoomPosition decodes the completion of ZoomPosInq
+func ZoomPosition(r Reply) (uint16, error) {
+	if err := r.expect(4); err != nil {
+		return 0, err
+	}
+	return word(r.Data)
+}
+
+// FocusPosition decodes the completion of FocusPosInq
+func FocusPosition(r Reply) (uint16, error) {
+	if err := r.expect(4); err != nil {
+		return 0, err
+	}
+	return word(r.Data)
+}
+
+// FocusMode decodes the completion of FocusModeInq (true = auto focus)
+func FocusMode(r Reply) (auto bool, err error) {
+	if err = r.expect(1); err != nil {
+		return
+	}
+	return r.Data[0] == 0x02, nil
+}
+
+// PowerState decodes the completion of PowerInq (true = on, false = standby)
+func PowerState(r Reply) (on bool, err error) {
+	if err = r.expect(1); err != nil {
+		return
+	}
+	return r.Data[0] == 0x02, nil
+}
+
+// check completion with at least n data bytes
+func (r Reply) expect(n int) error {
+	if err := r.Err(); err != nil {
+		return err
+	}
+	if r.Type != Completion {
+		return fmt.Errorf("visca: no completion: %v", r)
+	}
+	if len(r.Data) < n {
+		return fmt.Errorf("visca: reply too short (expected %d data bytes): %v", n, r)
+	}
+	return nil
+}
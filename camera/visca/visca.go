@@ -0,0 +1,281 @@
+// Package visca encodes VISCA commands and parses the replies of the camera.
+//
+// A message has a header (0x80 | receiver address, 0x88 = broadcast), the payload and the terminator 0xff.
+// Replies use header 0x80 | (camera address + 8) << 4, e.g. 0x90 for camera 1.
+package visca
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	Terminator = 0xff
+	Broadcast  = 8 // address of broadcast messages (address set, IF clear to all cameras)
+
+	MaxAddress    = 7
+	MaxPanSpeed   = 0x18
+	MaxTiltSpeed  = 0x14
+	MaxZoomSpeed  = 0x07
+	MaxFocusSpeed = 0x07
+)
+
+// Command is a VISCA payload without header and terminator
+type Command []byte
+
+// Encode builds the message of cmd for camera with given address (1..7 or Broadcast)
+func Encode(address byte, cmd Command) []byte {
+	msg := append([]byte{0x80 | address&0x0f}, cmd...)
+	return append(msg, Terminator)
+}
+
+func (c Command) String() string {
+	return hex.EncodeToString(c)
+}
+
+// Direction of pan/tilt drive
+type Direction int8
+
+const (
+	Stop Direction = 0
+
+	PanRight Direction = 1
+	PanLeft  Direction = -1
+	TiltUp   Direction = 1
+	TiltDown Direction = -1
+)
+
+// AddressSet assigns addresses to all daisy chained cameras (broadcast)
+func AddressSet() Command {
+	return Command{0x30, 0x01}
+}
+
+// IFClear clears the command buffers of the camera
+func IFClear() Command {
+	return Command{0x01, 0x00, 0x01}
+}
+
+// CommandCancel cancels the command executed in socket
+func CommandCancel(socket byte) Command {
+	return Command{0x20 | socket&0x0f}
+}
+
+// PanTiltDrive moves pan/tilt with speed 1..MaxPanSpeed/MaxTiltSpeed
+func PanTiltDrive(pan, tilt Direction, panSpeed, tiltSpeed byte) Command {
+	p, t := byte(0x03), byte(0x03)
+	switch {
+	case pan > 0:
+		p = 0x02
+	case pan < 0:
+		p = 0x01
+	}
+	switch {
+	case tilt > 0:
+		t = 0x01
+	case tilt < 0:
+		t = 0x02
+	}
+	return Command{0x01, 0x06, 0x01, panSpeed, tiltSpeed, p, t}
+}
+
+// PanTiltHome moves to home position
+func PanTiltHome() Command {
+	return Command{0x01, 0x06, 0x04}
+}
+
+// PanTiltReset initializes pan/tilt
+func PanTiltReset() Command {
+	return Command{0x01, 0x06, 0x05}
+}
+
+// PanTiltAbsolute moves to absolute pan/tilt position (camera specific units)
+func PanTiltAbsolute(pan, tilt int16, panSpeed, tiltSpeed byte) Command {
+	cmd := Command{0x01, 0x06, 0x02, panSpeed, tiltSpeed}
+	cmd = append(cmd, nibbles(uint16(pan))...)
+	return append(cmd, nibbles(uint16(tilt))...)
+}
+
+// ZoomStop stops zooming
+func ZoomStop() Command {
+	return Command{0x01, 0x04, 0x07, 0x00}
+}
+
+// ZoomTele zooms in with speed 0..MaxZoomSpeed
+func ZoomTele(speed byte) Command {
+	return Command{0x01, 0x04, 0x07, 0x20 | speed&0x07}
+}
+
+// ZoomWide zooms out with speed 0..MaxZoomSpeed
+func ZoomWide(speed byte) Command {
+	return Command{0x01, 0x04, 0x07, 0x30 | speed&0x07}
+}
+
+// ZoomDirect moves to absolute zoom position
+func ZoomDirect(position uint16) Command {
+	return append(Command{0x01, 0x04, 0x47}, nibbles(position)...)
+}
+
+// FocusStop stops focusing
+func FocusStop() Command {
+	return Command{0x01, 0x04, 0x08, 0x00}
+}
+
+// FocusFar focuses far with speed 0..MaxFocusSpeed
+func FocusFar(speed byte) Command {
+	return Command{0x01, 0x04, 0x08, 0x20 | speed&0x07}
+}
+
+// FocusNear focuses near with speed 0..MaxFocusSpeed
+func FocusNear(speed byte) Command {
+	return Command{0x01, 0x04, 0x08, 0x30 | speed&0x07}
+}
+
+// FocusDirect moves to absolute focus position
+func FocusDirect(position uint16) Command {
+	return append(Command{0x01, 0x04, 0x48}, nibbles(position)...)
+}
+
+// FocusAuto switches auto focus on
+func FocusAuto() Command {
+	return Command{0x01, 0x04, 0x38, 0x02}
+}
+
+// FocusManual switches auto focus off
+func FocusManual() Command {
+	return Command{0x01, 0x04, 0x38, 0x03}
+}
+
+// FocusOnePush triggers a single auto focus
+func FocusOnePush() Command {
+	return Command{0x01, 0x04, 0x18, 0x01}
+}
+
+// MemoryReset deletes a preset
+func MemoryReset(preset byte) Command {
+	return Command{0x01, 0x04, 0x3f, 0x00, preset & 0x7f}
+}
+
+// MemorySet stores the current position as preset
+func MemorySet(preset byte) Command {
+	return Command{0x01, 0x04, 0x3f, 0x01, preset & 0x7f}
+}
+
+// MemoryRecall moves to a preset
+func MemoryRecall(preset byte) Command {
+	return Command{0x01, 0x04, 0x3f, 0x02, preset & 0x7f}
+}
+
+// AE mode of Exposure
+const (
+	AEFullAuto        = 0x00
+	AEManual          = 0x03
+	AEShutterPriority = 0x0a
+	AEIrisPriority    = 0x0b
+	AEBright          = 0x0d
+)
+
+// Exposure selects the AE mode
+func Exposure(mode byte) Command {
+	return Command{0x01, 0x04, 0x39, mode}
+}
+
+// IrisUp opens the iris one step (manual or iris priority exposure)
+func IrisUp() Command {
+	return Command{0x01, 0x04, 0x0b, 0x02}
+}
+
+// IrisDown closes the iris one step
+func IrisDown() Command {
+	return Command{0x01, 0x04, 0x0b, 0x03}
+}
+
+// GainUp increases the gain one step (manual exposure)
+func GainUp() Command {
+	return Command{0x01, 0x04, 0x0c, 0x02}
+}
+
+// GainDown decreases the gain one step
+func GainDown() Command {
+	return Command{0x01, 0x04, 0x0c, 0x03}
+}
+
+// Backlight switches the backlight compensation on or off
+func Backlight(on bool) Command {
+	if on {
+		return Command{0x01, 0x04, 0x33, 0x02}
+	}
+	return Command{0x01, 0x04, 0x33, 0x03}
+}
+
+// mode of WhiteBalance
+const (
+	WBAuto    = 0x00
+	WBIndoor  = 0x01
+	WBOutdoor = 0x02
+	WBOnePush = 0x03
+	WBATW     = 0x04
+	WBManual  = 0x05
+)
+
+// WhiteBalance selects the white balance mode
+func WhiteBalance(mode byte) Command {
+	return Command{0x01, 0x04, 0x35, mode}
+}
+
+// WhiteBalanceTrigger adjusts the white balance once (one push mode)
+func WhiteBalanceTrigger() Command {
+	return Command{0x01, 0x04, 0x10, 0x05}
+}
+
+// Power switches camera on or to standby
+func Power(on bool) Command {
+	if on {
+		return Command{0x01, 0x04, 0x00, 0x02}
+	}
+	return Command{0x01, 0x04, 0x00, 0x03}
+}
+
+// PowerInq requests power state (see PowerState)
+func PowerInq() Command {
+	return Command{0x09, 0x04, 0x00}
+}
+
+// ZoomPosInq requests zoom position (see ZoomPosition)
+func ZoomPosInq() Command {
+	return Command{0x09, 0x04, 0x47}
+}
+
+// FocusPosInq requests focus position (see FocusPosition)
+func FocusPosInq() Command {
+	return Command{0x09, 0x04, 0x48}
+}
+
+// FocusModeInq requests focus mode (see FocusMode)
+func FocusModeInq() Command {
+	return Command{0x09, 0x04, 0x38}
+}
+
+// PanTiltPosInq requests pan/tilt position (see PanTiltPosition)
+func PanTiltPosInq() Command {
+	return Command{0x09, 0x06, 0x12}
+}
+
+// split 16 bit value into 4 nibbles (0p 0q 0r 0s)
+func nibbles(v uint16) []byte {
+	return []byte{byte(v>>12) & 0x0f, byte(v>>8) & 0x0f, byte(v>>4) & 0x0f, byte(v) & 0x0f}
+}
+
+// join 4 nibbles (0p 0q 0r 0s) to 16 bit value
+func word(b []byte) (uint16, error) {
+	if len(b) < 4 {
+		return 0, fmt.Errorf("visca: %d bytes too short for 4 nibbles", len(b))
+	}
+	var v uint16
+	for _, n := range b[:4] {
+		if n > 0x0f {
+			return 0, fmt.Errorf("visca: invalid nibble %#02x", n)
+		}
+		v = v<<4 | uint16(n)
+	}
+	return v, nil
+}
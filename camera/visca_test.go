@@ -0,0 +1,119 @@
+package camera
+
+import (
+	"camcontrol/camera/visca"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// fakeVisca answers the messages of the driver with scripted replies (hex, "" = no reply)
+func fakeVisca(t *testing.T, name string, replies map[string]string) *viscaCamera {
+	t.Helper()
+	replies["883001ff"] = "883002ff"     // address set
+	replies["88010001ff"] = "88010001ff" // IF clear
+	transport := NewMemoryTransport(name, func(request []byte) []byte {
+		r, ok := replies[hex.EncodeToString(request)]
+		if !ok {
+			t.Errorf("unexpected message %x", request)
+			return nil
+		}
+		b, _ := hex.DecodeString(strings.ReplaceAll(r, " ", ""))
+		return b
+	})
+	l, err := newTransportLink(transport, visca.ScanMessages, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := newVisca(l, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+// late completion of a preset recall (ACK only awaited) is dropped by the next inquiry
+func TestViscaLateCompletion(t *testing.T) {
+	c := fakeVisca(t, "visca-late", map[string]string{
+		"8101043f0203ff": "9041ff",                             // recall preset 3: ACK socket 1
+		"81090612ff":     "9051ff 9050 0000 0100 0000 0008 ff", // late completion, pan/tilt position
+		"81090447ff":     "9050 0200 0000 ff",                  // zoom position
+	})
+	if err := c.PresetSelect(3); err != nil {
+		t.Fatal(err)
+	}
+	p, err := c.Position()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.RawPan != 0x10 || p.RawTilt != 0x08 || p.RawZoom != 0x2000 {
+		t.Errorf("position %v", p)
+	}
+}
+
+// command waits for the completion of its own socket
+func TestViscaCompletionSocket(t *testing.T) {
+	c := fakeVisca(t, "visca-socket", map[string]string{
+		"8101043f0203ff": "9041ff",               // recall preset 3: ACK socket 1
+		"8101043f0104ff": "9042ff 9051ff 9052ff", // save preset 4: ACK socket 2, late completion 1, completion 2
+		"8101043f0005ff": "9041ff 9061 41ff",     // reset preset 5: ACK socket 1, not executable
+	})
+	if err := c.PresetSelect(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.PresetSave(4); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.sockets) != 0 {
+		t.Errorf("outstanding sockets %v", c.sockets)
+	}
+	if err := c.PresetReset(5); err == nil {
+		t.Errorf("reset preset: error of socket 1 ignored")
+	}
+}
+
+// cameras of a daisy chain share the link: the chain is addressed once, replies of the other camera are dropped
+func TestViscaDaisyChain(t *testing.T) {
+	replies := map[string]string{
+		"883001ff":       "883003ff",                      // address set: two cameras
+		"88010001ff":     "88010001ff",                    // IF clear
+		"8101043f0104ff": "a041ff a06141ff 9041ff 9051ff", // save preset 4 of camera 1 after late replies of camera 2
+		"8201043f0104ff": "9052ff a042ff a052ff",          // save preset 4 of camera 2 after late completion of camera 1
+	}
+	counts := map[string]int{}
+	transport := NewMemoryTransport("visca-chain", func(request []byte) []byte {
+		counts[hex.EncodeToString(request)]++
+		r, ok := replies[hex.EncodeToString(request)]
+		if !ok {
+			t.Errorf("unexpected message %x", request)
+			return nil
+		}
+		b, _ := hex.DecodeString(strings.ReplaceAll(r, " ", ""))
+		return b
+	})
+	var cams []*viscaCamera
+	for _, address := range []byte{1, 2} {
+		l, err := newTransportLink(transport, visca.ScanMessages, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := newVisca(l, address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(c.Close)
+		cams = append(cams, c)
+	}
+	if counts["883001ff"] != 1 || counts["88010001ff"] != 1 {
+		t.Errorf("daisy chain initialized %d times", counts["883001ff"])
+	}
+	for _, c := range cams {
+		if err := c.PresetSave(4); err != nil {
+			t.Errorf("camera %d: %v", c.address, err)
+		}
+		if len(c.sockets) != 0 {
+			t.Errorf("camera %d: outstanding sockets %v", c.address, c.sockets)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package camera
+
+import (
+	"camcontrol/camera/visca"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// VISCA over IP (Sony): every UDP packet starts with payload type (2 bytes), payload length (2 bytes)
+// and sequence number (4 bytes) followed by the VISCA message or a control command
+
+const (
+	viscaIPPort    = "52381"
+	viscaIPTimeout = 200 * time.Millisecond // wait for reply before retransmission
+	viscaIPRetries = 3
+
+	viscaIPHeaderSize = 8
+
+	viscaIPCommand      = 0x0100
+	viscaIPInquiry      = 0x0110
+	viscaIPReply        = 0x0111
+	viscaIPControl      = 0x0200
+	viscaIPControlReply = 0x0201
+)
+
+var errViscaIPSequence = errors.New("visca-ip: abnormality in sequence number")
+
+// UDP transport of VISCA messages (sequence numbers, reset and retransmission)
+type viscaIP struct {
+	addr string     // host:port of camera
+	conn net.Conn   // UDP socket, nil = not connected
+	seq  uint32     // sequence number of next packet
+	mu   sync.Mutex // socket is used by UI and position readout
+}
+
+func init() {
+	Register(Driver{
+		Name:        "visca-ip",
+		Description: "VISCA over IP camera (UDP port 52381)",
+		Schema:      Schema{Host: true, MinAddress: 1, MaxAddress: visca.MaxAddress},
+		New: func(o Options) (Camera, error) {
+			cam, err := NewViscaIP(o.Host, o.Address)
+			if cam == nil {
+				return nil, err
+			}
+			return cam, err
+		},
+	})
+}
+
+// create camera object for VISCA over IP camera (host with optional port, default port 52381)
+func NewViscaIP(host string, address byte) (*viscaCamera, error) {
+	if address < 1 || address > visca.MaxAddress {
+		return nil, fmt.Errorf("invalid VISCA camera address %d (1..%d)", address, visca.MaxAddress)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, viscaIPPort)
+	}
+	c := viscaCamera{
+		link:    &viscaIP{addr: host},
+		address: address,
+		sockets: map[byte]bool{},
+	}
+	return &c, c.connect()
+}
+
+// connect socket and reset sequence number (mu is locked by send)
+func (v *viscaIP) connect() (err error) {
+	v.close()
+	if v.conn, err = net.Dial("udp", v.addr); err != nil {
+		v.conn = nil
+		return fmt.Errorf("visca-ip: connect %v failed: %v", v.addr, err)
+	}
+	log.Printf("VISCA over IP use camera %v", v.addr)
+	if err = v.reset(); err != nil {
+		v.close()
+	}
+	return
+}
+
+func (v *viscaIP) Close() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.close()
+}
+
+func (v *viscaIP) close() {
+	if v.conn != nil {
+		_ = v.conn.Close()
+		v.conn = nil
+	}
+}
+
+// reset sequence number of camera (control command RESET)
+func (v *viscaIP) reset() error {
+	if _, err := v.exchange(viscaIPControl, []byte{0x01}, response{}); err != nil {
+		return err
+	}
+	v.seq = 0
+	return nil
+}
+
+// write VISCA message and return the replies of the camera
+func (v *viscaIP) send(msg []byte, r response) ([][]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.conn == nil {
+		if err := v.connect(); err != nil {
+			return nil, fmt.Errorf("failed writing to camera: %v", err)
+		}
+	}
+	payloadType := uint16(viscaIPCommand)
+	if len(msg) > 1 && msg[1] == 0x09 {
+		payloadType = viscaIPInquiry
+	}
+	frames, err := v.exchange(payloadType, msg, r)
+	if errors.Is(err, errViscaIPSequence) {
+		log.Printf("%v, reset sequence number...", err)
+		if err = v.reset(); err == nil {
+			frames, err = v.exchange(payloadType, msg, r)
+		}
+	}
+	var timeout *TimeoutError
+	if err != nil && !errors.As(err, &timeout) {
+		return nil, fmt.Errorf("failed writing to camera: %v", err)
+	}
+	return frames, err
+}
+
+// send packet with current sequence number (retransmit without reply) and read the replies
+func (v *viscaIP) exchange(payloadType uint16, payload []byte, r response) ([][]byte, error) {
+	packet := make([]byte, viscaIPHeaderSize, viscaIPHeaderSize+len(payload))
+	binary.BigEndian.PutUint16(packet[0:], payloadType)
+	binary.BigEndian.PutUint16(packet[2:], uint16(len(payload)))
+	binary.BigEndian.PutUint32(packet[4:], v.seq)
+	packet = append(packet, payload...)
+	defer func() { v.seq++ }()
+
+	for i := 0; i <= viscaIPRetries; i++ {
+		if _, err := v.conn.Write(packet); err != nil {
+			return nil, err
+		}
+		log.Printf("Wrote %d bytes: %s\n", len(packet), hex.EncodeToString(packet))
+		frames, err := v.receive(payload, r)
+		if err == errFrameTimeout {
+			log.Printf("visca-ip: no reply (seq %d), retransmit...", v.seq)
+			continue
+		}
+		return frames, err
+	}
+	return nil, &TimeoutError{Request: payload, Wait: viscaIPTimeout * (viscaIPRetries + 1)}
+}
+
+// read replies of current sequence number until the response is complete, error or control reply
+// (errFrameTimeout without reply, TimeoutError if the response is not complete until its deadline)
+func (v *viscaIP) receive(msg []byte, r response) ([][]byte, error) {
+	var frames [][]byte
+	buf := make([]byte, 1500)
+	_ = v.conn.SetReadDeadline(time.Now().Add(viscaIPTimeout))
+	for {
+		n, err := v.conn.Read(buf)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			if len(frames) > 0 {
+				return frames, &TimeoutError{Request: msg, Wait: r.timeout}
+			}
+			return nil, errFrameTimeout
+		}
+		if err != nil {
+			return frames, err
+		}
+		if n < viscaIPHeaderSize {
+			log.Printf("visca-ip: packet too short: %s", hex.EncodeToString(buf[:n]))
+			continue
+		}
+		payloadType := binary.BigEndian.Uint16(buf[0:])
+		length := int(binary.BigEndian.Uint16(buf[2:]))
+		seq := binary.BigEndian.Uint32(buf[4:])
+		if length > n-viscaIPHeaderSize {
+			log.Printf("visca-ip: invalid payload length: %s", hex.EncodeToString(buf[:n]))
+			continue
+		}
+		payload := append([]byte{}, buf[viscaIPHeaderSize:viscaIPHeaderSize+length]...)
+		switch payloadType {
+		case viscaIPControlReply:
+			if len(payload) >= 2 && payload[0] == 0x0f {
+				if payload[1] == 0x01 {
+					return nil, errViscaIPSequence
+				}
+				return nil, fmt.Errorf("visca-ip: abnormality in message: %s", hex.EncodeToString(payload))
+			}
+			return nil, nil
+		case viscaIPReply:
+			if seq != v.seq {
+				log.Printf("visca-ip: ignore reply of seq %d: %s", seq, hex.EncodeToString(payload))
+				continue
+			}
+			log.Printf("Response : %s\n", hex.EncodeToString(payload))
+			frames = append(frames, payload)
+			if r.done == nil || r.done(payload) {
+				return frames, nil
+			}
+			// e.g. ACK, wait for completion
+			_ = v.conn.SetReadDeadline(time.Now().Add(r.timeout))
+		default:
+			log.Printf("visca-ip: ignore payload type %#04x: %s", payloadType, hex.EncodeToString(payload))
+		}
+	}
+}
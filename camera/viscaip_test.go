@@ -0,0 +1,118 @@
+package camera
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeViscaIP is a VISCA over IP camera on a local UDP socket, handle returns the reply packets
+// of a received packet (payload type and hex payload, e.g. "0111 9041ff")
+func fakeViscaIP(t *testing.T, handle func(packet string, seq uint32) []string) (addr string, received func() []string) {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	var (
+		mu      sync.Mutex
+		packets []string
+	)
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if n < viscaIPHeaderSize || int(binary.BigEndian.Uint16(buf[2:])) != n-viscaIPHeaderSize {
+				t.Errorf("invalid packet %x", buf[:n])
+				continue
+			}
+			seq := binary.BigEndian.Uint32(buf[4:])
+			packet := fmt.Sprintf("%04x %x", binary.BigEndian.Uint16(buf), buf[viscaIPHeaderSize:n])
+			mu.Lock()
+			packets = append(packets, fmt.Sprintf("%v seq %d", packet, seq))
+			mu.Unlock()
+			for _, reply := range handle(packet, seq) {
+				b, err := hex.DecodeString(strings.ReplaceAll(reply, " ", ""))
+				if err != nil {
+					t.Errorf("invalid reply %v", reply)
+					continue
+				}
+				p := make([]byte, viscaIPHeaderSize, viscaIPHeaderSize+len(b)-2)
+				copy(p, b[:2])
+				binary.BigEndian.PutUint16(p[2:], uint16(len(b)-2))
+				binary.BigEndian.PutUint32(p[4:], seq)
+				_, _ = conn.WriteToUDP(append(p, b[2:]...), from)
+			}
+		}
+	}()
+	return conn.LocalAddr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, packets...)
+	}
+}
+
+// sequence numbers, RESET, retransmission without reply and reset after abnormality in sequence number
+func TestViscaIP(t *testing.T) {
+	var retransmitted, resequenced bool
+	addr, received := fakeViscaIP(t, func(packet string, seq uint32) []string {
+		switch packet {
+		case "0200 01": // RESET
+			return []string{"0201 01"}
+		case "0100 8101043f0101ff": // save preset 1
+			return []string{"0111 9041ff", "0111 9051ff"}
+		case "0110 81090400ff": // power inquiry, first packet is lost
+			if !retransmitted {
+				retransmitted = true
+				return nil
+			}
+			return []string{"0111 905002ff"}
+		case "0100 8101043f0202ff": // recall preset 2, first packet with unexpected sequence number
+			if !resequenced {
+				resequenced = true
+				return []string{"0201 0f01"}
+			}
+			return []string{"0111 9041ff"}
+		}
+		t.Errorf("unexpected packet %v", packet)
+		return nil
+	})
+
+	c, err := NewViscaIP(addr, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.PresetSave(1); err != nil {
+		t.Fatal(err)
+	}
+	on, err := c.PowerState()
+	if err != nil || !on {
+		t.Errorf("PowerState() = %v, %v, want true", on, err)
+	}
+	if err := c.PresetSelect(2); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"0200 01 seq 0",
+		"0100 8101043f0101ff seq 0",
+		"0110 81090400ff seq 1",
+		"0110 81090400ff seq 1",
+		"0100 8101043f0202ff seq 2",
+		"0200 01 seq 3",
+		"0100 8101043f0202ff seq 0",
+	}
+	if got := received(); !reflect.DeepEqual(got, want) {
+		t.Errorf("received packets\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
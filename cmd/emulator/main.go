@@ -0,0 +1,99 @@
+// Command emulator behaves like a Pelco-D (e.g. Tenveo NV10U), Pelco-P or VISCA camera on the slave side of a pseudo-terminal (Linux),
+// therefore camera control is tested end to end on the serial path without hardware, e.g.
+//
+//	go run ./cmd/emulator -protocol=visca -address=1
+//	Emulated visca camera 1 on /dev/pts/3
+//
+// and camera control started with -PROTOCOL=visca -DEVICE=/dev/pts/3.
+// The camera is simulated by package camera/simulator, quirks of real cameras are switched on by flags.
+package main
+
+import (
+	"camcontrol/camera/simulator"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+)
+
+var (
+	protocolArg     = flag.String("protocol", "pelco-d", "Protocol of the camera: pelco-d, tenveo-nv10u, pelco-p or visca")
+	addressArg      = flag.Uint("address", 1, "Address of the camera: Pelco-D/Pelco-P 1..255 (Pelco-P address + 1 like -ADDRESS), VISCA 1..7")
+	tenveoArg       = flag.Bool("tenveo", false, "Pelco-D: zoom speed is read of pan speed like the Tenveo NV10U (default of tenveo-nv10u)")
+	echoArg         = flag.Bool("echo", false, "Pelco: commands without response are echoed")
+	badChecksumArg  = flag.Bool("badchecksum", false, "Pelco: responses with wrong checksum")
+	noCompletionArg = flag.Bool("nocompletion", false, "VISCA: commands are acknowledged without completion")
+	delayArg        = flag.Duration("delay", 0, "Delay of responses, e.g. 200ms (longer than the read timeout of the driver = no response)")
+	linkArg         = flag.String("link", "", "Symbolic link to the pseudo-terminal, e.g. /tmp/ttyCAM0 (stable device name for -DEVICE)")
+)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// emulate the camera until interrupt or failure of the pseudo-terminal (link is removed on return)
+func run() error {
+	if *addressArg > 0xff {
+		return fmt.Errorf("invalid address %d", *addressArg)
+	}
+	cam, err := simulator.New(simulator.Protocol(*protocolArg), byte(*addressArg))
+	if err != nil {
+		return err
+	}
+	cam.Tenveo = *tenveoArg || cam.Tenveo
+	cam.Echo = *echoArg
+	cam.BadChecksum = *badChecksumArg
+	cam.NoCompletion = *noCompletionArg
+
+	pty, slave, name, err := openPty()
+	if err != nil {
+		return fmt.Errorf("open pseudo-terminal failed: %v", err)
+	}
+	defer pty.Close()
+	defer slave.Close()
+	if *linkArg != "" {
+		_ = os.Remove(*linkArg)
+		if err := os.Symlink(name, *linkArg); err != nil {
+			return fmt.Errorf("link %v failed: %v", *linkArg, err)
+		}
+		defer os.Remove(*linkArg)
+		name = *linkArg
+	}
+	fmt.Printf("Emulated %v camera %d on %v\n", *protocolArg, *addressArg, name)
+
+	failed := make(chan error, 1)
+	go func() { failed <- serve(pty, cam) }()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	select {
+	case <-interrupt:
+	case err = <-failed:
+	}
+	log.Printf("Stopped (%v)\n", cam.State())
+	return err
+}
+
+// answer the frames received on the pseudo-terminal until it fails
+func serve(pty io.ReadWriter, cam *simulator.Camera) error {
+	buf := make([]byte, 256)
+	for {
+		n, err := pty.Read(buf)
+		if err != nil {
+			return fmt.Errorf("read failed: %v", err)
+		}
+		r := cam.Handle(buf[:n])
+		if len(r) == 0 {
+			continue
+		}
+		time.Sleep(*delayArg)
+		if _, err := pty.Write(r); err != nil {
+			return fmt.Errorf("write failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// openPty returns master and slave of a new pseudo-terminal and the path of the slave, e.g. /dev/pts/3.
+// The slave has to be kept open (raw mode), therefore reading the master does not fail while the camera port is closed.
+func openPty() (master, slave *os.File, name string, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	var unlock int32
+	if err := ioctl(master, syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		master.Close()
+		return nil, nil, "", fmt.Errorf("unlock: %v", err)
+	}
+	var n uint32
+	if err := ioctl(master, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); err != nil {
+		master.Close()
+		return nil, nil, "", fmt.Errorf("ptsname: %v", err)
+	}
+	name = fmt.Sprintf("/dev/pts/%d", n)
+	slave, err = os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, "", err
+	}
+	// no echo or line editing until the port is opened (binary frames)
+	var t syscall.Termios
+	if err := ioctl(slave, syscall.TCGETS, uintptr(unsafe.Pointer(&t))); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, nil, "", fmt.Errorf("get terminal attributes: %v", err)
+	}
+	t.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	t.Oflag &^= syscall.OPOST
+	t.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	if err := ioctl(slave, syscall.TCSETS, uintptr(unsafe.Pointer(&t))); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, nil, "", fmt.Errorf("set terminal attributes: %v", err)
+	}
+	return master, slave, name, nil
+}
+
+func ioctl(f *os.File, request, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), request, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
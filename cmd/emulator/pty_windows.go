@@ -0,0 +1,11 @@
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// openPty is not available, a virtual COM port pair (e.g. com0com) is needed on Windows
+func openPty() (master, slave *os.File, name string, err error) {
+	return nil, nil, "", errors.New("pseudo-terminals are supported on Linux only")
+}
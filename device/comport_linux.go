@@ -0,0 +1,100 @@
+package device
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// serial ports are read from sysfs: /sys/class/tty/<name>/device links to the USB interface
+// (ttyACM) or USB serial port (ttyUSB), the USB device with vendor/product ID is one of its parents
+
+const sysClassTTY = "/sys/class/tty"
+
+// Init is not needed on Linux (compatibility with Windows)
+func Init() {
+}
+
+// Exit is not needed on Linux (compatibility with Windows)
+func Exit() {
+}
+
+// PortPath returns the device path of port number, e.g. /dev/ttyUSB0
+func PortPath(portNo int) string {
+	return "/dev/ttyUSB" + strconv.Itoa(portNo)
+}
+
+// GetPortList returns USB serial ports (/dev/ttyUSB* and /dev/ttyACM*) with the properties of the USB device
+func GetPortList() []Port {
+	list := []Port{}
+	entries, err := ioutil.ReadDir(sysClassTTY)
+	if err != nil {
+		return list
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "ttyUSB") && !strings.HasPrefix(name, "ttyACM") {
+			continue
+		}
+		dev, err := filepath.EvalSymlinks(filepath.Join(sysClassTTY, name, "device"))
+		if err != nil {
+			continue
+		}
+		p := Port{Path: "/dev/" + name}
+		if usb := usbDevice(dev); usb != "" {
+			p.VID = readAttr(usb, "idVendor")
+			p.PID = readAttr(usb, "idProduct")
+			p.Serial = readAttr(usb, "serial")
+			p.Product = readAttr(usb, "product")
+		}
+		p.Name = p.Path
+		if p.Product != "" {
+			p.Name = p.Product + " (" + p.Path + ")"
+		}
+		list = append(list, p)
+	}
+	return list
+}
+
+// helper for reading all available port names, e.g. "USB Serial (/dev/ttyUSB0)"
+func GetDeviceClassPortNameList() []string {
+	list := []string{}
+	for _, p := range GetPortList() {
+		list = append(list, p.Name)
+	}
+	return list
+}
+
+// search USB device (directory with idVendor) in parents of sysfs device directory
+func usbDevice(dir string) string {
+	for i := 0; i < 4 && dir != "/"; i++ {
+		if _, err := os.Stat(filepath.Join(dir, "idVendor")); err == nil {
+			return dir
+		}
+		dir = filepath.Dir(dir)
+	}
+	return ""
+}
+
+func readAttr(dir, name string) string {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// paths are the same device (e.g. /dev/serial/by-id/<name> is a link to /dev/ttyUSB<n>)
+func samePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ra, err := filepath.EvalSymlinks(a)
+	if err != nil {
+		return false
+	}
+	rb, err := filepath.EvalSymlinks(b)
+	return err == nil && ra == rb
+}
@@ -2,6 +2,8 @@ package device
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"syscall"
 	"unsafe"
@@ -25,18 +27,22 @@ const (
 	dIGCF_PRESENT        = uint32(0x2)
 	iNVALID_HANDLE_VALUE = uintptr(0xffffffff)
 	sPDRP_FRIENDLYNAME   = uintptr(0x0000000C)
+	sPDRP_HARDWAREID     = uintptr(0x00000001)
 )
 
 var (
 	setupDiGetClassDevs,
 	setupDiGetDeviceRegistryProperty,
-	setupDiEnumDeviceInfo uintptr
+	setupDiGetDeviceInstanceId,
+	setupDiEnumDeviceInfo,
+	setupDiDestroyDeviceInfoList uintptr
 	gUID_DEVCLASS_PORTS = gUID{0x4d36e978, 0xe325, 0x11ce, [8]byte{0xbf, 0xc1, 0x08, 0x00, 0x2b, 0xe1, 0x03, 0x18}}
 	setupApi            syscall.Handle
 )
 
 func Init() {
-	setupApi, err := syscall.LoadLibrary("setupapi.dll")
+	var err error
+	setupApi, err = syscall.LoadLibrary("setupapi.dll")
 	if err != nil {
 		panic("LoadLibrary " + err.Error())
 	}
@@ -44,6 +50,8 @@ func Init() {
 	setupDiGetClassDevs = getProcAddr(setupApi, "SetupDiGetClassDevsA")
 	setupDiEnumDeviceInfo = getProcAddr(setupApi, "SetupDiEnumDeviceInfo")
 	setupDiGetDeviceRegistryProperty = getProcAddr(setupApi, "SetupDiGetDeviceRegistryPropertyA")
+	setupDiGetDeviceInstanceId = getProcAddr(setupApi, "SetupDiGetDeviceInstanceIdA")
+	setupDiDestroyDeviceInfoList = getProcAddr(setupApi, "SetupDiDestroyDeviceInfoList")
 }
 
 func Exit() {
@@ -58,46 +66,153 @@ func getProcAddr(lib syscall.Handle, name string) uintptr {
 	return addr
 }
 
-// read available device port "friendly" name of windows (LPTx/COMx)
-func getDeviceClassPortName(device uint32) (result string, err error) {
+// device information set of the present ports (COM/LPT), release it with destroyDeviceInfoList
+func getDeviceClassPorts() (uintptr, error) {
 	hDeviceInfo, _, err := syscall.Syscall6(setupDiGetClassDevs, 4, uintptr(unsafe.Pointer(&gUID_DEVCLASS_PORTS)), 0, 0, uintptr(dIGCF_PRESENT), 0, 0)
 	if hDeviceInfo == iNVALID_HANDLE_VALUE || hDeviceInfo == 0 {
-		return result, fmt.Errorf("setupDiGetClassDevs failed %v, %v", hDeviceInfo, err)
+		return 0, fmt.Errorf("setupDiGetClassDevs failed %v, %v", hDeviceInfo, err)
 	}
+	return hDeviceInfo, nil
+}
+
+func destroyDeviceInfoList(hDeviceInfo uintptr) {
+	_, _, _ = syscall.Syscall(setupDiDestroyDeviceInfoList, 1, hDeviceInfo, 0, 0)
+}
 
-	var dd sP_DEVINFO_DATA
+// device of the information set by index (error after the last device)
+func getDeviceInfo(hDeviceInfo uintptr, device uint32) (dd sP_DEVINFO_DATA, err error) {
 	dd.cbSize = uint32(unsafe.Sizeof(dd))
-	r1, _, err := syscall.Syscall(setupDiEnumDeviceInfo, 3, uintptr(hDeviceInfo), uintptr(device), uintptr(unsafe.Pointer(&dd)))
+	r1, _, e := syscall.Syscall(setupDiEnumDeviceInfo, 3, hDeviceInfo, uintptr(device), uintptr(unsafe.Pointer(&dd)))
 	if r1 == 0 {
-		return result, fmt.Errorf("setupDiEnumDeviceInfo failed %v, %v", r1, err)
+		return dd, fmt.Errorf("setupDiEnumDeviceInfo failed %v, %v", r1, e)
 	}
+	return dd, nil
+}
 
+// read registry property of device (first string of multi strings)
+func getDeviceProperty(hDeviceInfo uintptr, dd *sP_DEVINFO_DATA, property uintptr) (result string, err error) {
 	requiredSize := uint32(0)
-	r1, _, err = syscall.Syscall9(setupDiGetDeviceRegistryProperty, 7, uintptr(hDeviceInfo), uintptr(unsafe.Pointer(&dd)), sPDRP_FRIENDLYNAME, 0, 0, 0, uintptr(unsafe.Pointer(&requiredSize)), 0, 0)
+	_, _, err = syscall.Syscall9(setupDiGetDeviceRegistryProperty, 7, hDeviceInfo, uintptr(unsafe.Pointer(dd)), property, 0, 0, 0, uintptr(unsafe.Pointer(&requiredSize)), 0, 0)
 	if requiredSize == 0 {
 		return result, fmt.Errorf("setupDiGetDeviceRegistryProperty requiredSize failed %v", err)
 	}
 
 	size := requiredSize
 	p := make([]byte, size)
-	r1, _, err = syscall.Syscall9(setupDiGetDeviceRegistryProperty, 7, uintptr(hDeviceInfo), uintptr(unsafe.Pointer(&dd)), sPDRP_FRIENDLYNAME, 0, uintptr(unsafe.Pointer(&p[0])), uintptr(size), 0, 0, 0)
+	r1, _, err := syscall.Syscall9(setupDiGetDeviceRegistryProperty, 7, hDeviceInfo, uintptr(unsafe.Pointer(dd)), property, 0, uintptr(unsafe.Pointer(&p[0])), uintptr(size), 0, 0, 0)
 	if r1 == 0 {
 		return result, fmt.Errorf("setupDiGetDeviceRegistryProperty failed %v", err)
 	}
-	result = strings.Trim(*(*string)(unsafe.Pointer(&p)), " \n\t\x00")
+	result = strings.Trim(strings.SplitN(string(p), "\x00", 2)[0], " \n\t")
 
 	return result, nil
 }
 
+// read device instance ID of device, e.g. "USB\VID_0403&PID_6001\A10K5XYZ"
+func getDeviceInstanceId(hDeviceInfo uintptr, dd *sP_DEVINFO_DATA) (result string, err error) {
+	p := make([]byte, 256)
+	r1, _, err := syscall.Syscall6(setupDiGetDeviceInstanceId, 5, hDeviceInfo, uintptr(unsafe.Pointer(dd)), uintptr(unsafe.Pointer(&p[0])), uintptr(len(p)), 0, 0)
+	if r1 == 0 {
+		return result, fmt.Errorf("setupDiGetDeviceInstanceId failed %v", err)
+	}
+	return strings.SplitN(string(p), "\x00", 2)[0], nil
+}
+
 // helper for reading all available device port "friendly" names of windows (LPTx/COMx)
 func GetDeviceClassPortNameList() []string {
 	list := []string{}
+	hDeviceInfo, err := getDeviceClassPorts()
+	if err != nil {
+		return list
+	}
+	defer destroyDeviceInfoList(hDeviceInfo)
 	for dev := uint32(0); ; dev++ {
-		if s, err := getDeviceClassPortName(dev); err == nil {
+		dd, err := getDeviceInfo(hDeviceInfo, dev)
+		if err != nil {
+			break
+		}
+		if s, err := getDeviceProperty(hDeviceInfo, &dd, sPDRP_FRIENDLYNAME); err == nil {
 			list = append(list, s)
-		} else {
+		}
+	}
+	return list
+}
+
+// PortPath returns the device name of port number, e.g. COM3
+func PortPath(portNo int) string {
+	return "COM" + strconv.Itoa(portNo)
+}
+
+var (
+	vidPattern = regexp.MustCompile(`(?i)VID_([0-9a-f]{4})`)
+	pidPattern = regexp.MustCompile(`(?i)PID_([0-9a-f]{4})`)
+)
+
+// GetPortList returns the COM ports with device name from "friendly" name "<name> (COM<n>)",
+// USB vendor/product ID from hardware ID and serial number from device instance ID
+func GetPortList() []Port {
+	list := []Port{}
+	hDeviceInfo, err := getDeviceClassPorts()
+	if err != nil {
+		return list
+	}
+	defer destroyDeviceInfoList(hDeviceInfo)
+	for dev := uint32(0); ; dev++ {
+		dd, err := getDeviceInfo(hDeviceInfo, dev)
+		if err != nil {
 			break
 		}
+		name, err := getDeviceProperty(hDeviceInfo, &dd, sPDRP_FRIENDLYNAME)
+		if err != nil {
+			continue
+		}
+		start := strings.LastIndex(name, "(COM")
+		end := strings.LastIndex(name, ")")
+		if start < 0 || end < start {
+			continue
+		}
+		p := Port{
+			Path:    name[start+1 : end],
+			Name:    name,
+			Product: strings.TrimSpace(name[:start]),
+		}
+		if id, err := getDeviceProperty(hDeviceInfo, &dd, sPDRP_HARDWAREID); err == nil {
+			if m := vidPattern.FindStringSubmatch(id); m != nil {
+				p.VID = strings.ToLower(m[1])
+			}
+			if m := pidPattern.FindStringSubmatch(id); m != nil {
+				p.PID = strings.ToLower(m[1])
+			}
+		}
+		if id, err := getDeviceInstanceId(hDeviceInfo, &dd); err == nil {
+			p.Serial = serialNumber(id)
+		}
+		list = append(list, p)
 	}
 	return list
 }
+
+// serial number of device instance ID: "USB\VID_0403&PID_6001\<serial>" or "FTDIBUS\VID_0403+PID_6001+<serial>A\0000"
+// (IDs generated by windows contain '&', the adapter has no serial number)
+func serialNumber(instanceId string) string {
+	parts := strings.Split(instanceId, "\\")
+	if len(parts) < 3 {
+		return ""
+	}
+	switch strings.ToUpper(parts[0]) {
+	case "USB":
+		if !strings.Contains(parts[2], "&") {
+			return parts[2]
+		}
+	case "FTDIBUS":
+		if ids := strings.Split(parts[1], "+"); len(ids) == 3 && len(ids[2]) > 1 {
+			return ids[2][:len(ids[2])-1]
+		}
+	}
+	return ""
+}
+
+// port names of windows are case insensitive
+func samePath(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
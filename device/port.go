@@ -0,0 +1,21 @@
+// Package device lists the serial ports of the system (Windows: setupapi.dll, Linux: sysfs)
+package device
+
+import "fmt"
+
+// Port is a serial port of the system
+type Port struct {
+	Path    string // name for opening the port, e.g. COM3 or /dev/ttyUSB0
+	Name    string // "friendly" name, e.g. "USB-SERIAL CH340 (COM3)"
+	VID     string // USB vendor ID (4 hex digits, empty if unknown), e.g. 1a86
+	PID     string // USB product ID (4 hex digits, empty if unknown), e.g. 7523
+	Serial  string // USB serial number (empty if not supported by adapter)
+	Product string // USB product string
+}
+
+func (p Port) String() string {
+	if p.VID == "" {
+		return p.Name
+	}
+	return fmt.Sprintf("%v [%v:%v %v]", p.Name, p.VID, p.PID, p.Serial)
+}
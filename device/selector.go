@@ -0,0 +1,129 @@
+package device
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Selector selects serial ports by USB properties, port path or name (empty fields match every port)
+type Selector struct {
+	VID    string         // USB vendor ID, e.g. 1a86
+	PID    string         // USB product ID, e.g. 7523
+	Serial string         // USB serial number
+	Path   string         // port, e.g. COM3 or /dev/ttyUSB0
+	Name   *regexp.Regexp // pattern of "friendly" name, e.g. "USB-SERIAL CH34"
+}
+
+// DefaultSelector selects the CH340/CH341 adapters (WCH) of the Tenveo NV10U
+var DefaultSelector = Selector{VID: "1a86"}
+
+// ParseSelector parses comma separated key=value pairs with keys vid, pid, serial, path and name,
+// e.g. "vid=0403,pid=6001,serial=A10K5XYZ" or "name=USB-SERIAL CH34" (a value without key is the path)
+func ParseSelector(s string) (sel Selector, err error) {
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, value := "path", field
+		if i := strings.Index(field, "="); i >= 0 {
+			key, value = strings.ToLower(strings.TrimSpace(field[:i])), strings.TrimSpace(field[i+1:])
+		}
+		if value == "" {
+			return sel, fmt.Errorf("invalid device selector '%v': empty value of '%v'", s, key)
+		}
+		switch key {
+		case "vid":
+			sel.VID, err = parseID(value)
+		case "pid":
+			sel.PID, err = parseID(value)
+		case "serial":
+			sel.Serial = value
+		case "path":
+			sel.Path = value
+		case "name":
+			sel.Name, err = regexp.Compile(value)
+		default:
+			err = fmt.Errorf("unknown key '%v' (vid, pid, serial, path, name)", key)
+		}
+		if err != nil {
+			return sel, fmt.Errorf("invalid device selector '%v': %v", s, err)
+		}
+	}
+	return sel, nil
+}
+
+// USB ID as 4 lower case hex digits
+func parseID(s string) (string, error) {
+	s = strings.TrimPrefix(strings.ToLower(s), "0x")
+	if _, err := strconv.ParseUint(s, 16, 16); err != nil || len(s) > 4 {
+		return "", fmt.Errorf("invalid USB ID '%v'", s)
+	}
+	return fmt.Sprintf("%04s", s), nil
+}
+
+// IsEmpty is true if the selector matches every port
+func (s Selector) IsEmpty() bool {
+	return s.VID == "" && s.PID == "" && s.Serial == "" && s.Path == "" && s.Name == nil
+}
+
+// Match checks the port properties
+func (s Selector) Match(p Port) bool {
+	return (s.VID == "" || strings.EqualFold(s.VID, p.VID)) &&
+		(s.PID == "" || strings.EqualFold(s.PID, p.PID)) &&
+		(s.Serial == "" || s.Serial == p.Serial) &&
+		(s.Path == "" || samePath(s.Path, p.Path)) &&
+		(s.Name == nil || s.Name.MatchString(p.Name))
+}
+
+func (s Selector) String() string {
+	fields := []string{}
+	for _, f := range []struct{ key, value string }{{"vid", s.VID}, {"pid", s.PID}, {"serial", s.Serial}, {"path", s.Path}} {
+		if f.value != "" {
+			fields = append(fields, f.key+"="+f.value)
+		}
+	}
+	if s.Name != nil {
+		fields = append(fields, "name="+s.Name.String())
+	}
+	return strings.Join(fields, ",")
+}
+
+// ports of the system (replaced by tests)
+var listPorts = func() []Port {
+	Init()
+	defer Exit()
+	return GetPortList()
+}
+
+// Find returns the ports of the system matching the selector
+func Find(sel Selector) []Port {
+	list := []Port{}
+	for _, p := range listPorts() {
+		if sel.Match(p) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// Resolve returns the port paths for the selector: an empty selector prefers the ports of DefaultSelector
+// and falls back to all ports, a path only selector is used as is (e.g. for ports which are not listed)
+func Resolve(sel Selector) []string {
+	ports := Find(sel)
+	if sel.IsEmpty() {
+		if preferred := Find(DefaultSelector); len(preferred) > 0 {
+			ports = preferred
+		}
+	}
+	paths := []string{}
+	for _, p := range ports {
+		paths = append(paths, p.Path)
+	}
+	if len(paths) == 0 && sel.Path != "" && (Selector{Path: sel.Path}) == sel {
+		paths = append(paths, sel.Path)
+	}
+	return paths
+}
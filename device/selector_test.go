@@ -0,0 +1,114 @@
+package device
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string // String() of the selector, "error" = invalid
+	}{
+		{"", ""},
+		{"vid=1a86", "vid=1a86"},
+		{"VID=1A86, PID=0x7523", "vid=1a86,pid=7523"},
+		{"vid=403,pid=6001,serial=A10K5XYZ", "vid=0403,pid=6001,serial=A10K5XYZ"},
+		{"path=/dev/ttyUSB0", "path=/dev/ttyUSB0"},
+		{"COM3", "path=COM3"},
+		{"name=USB-SERIAL CH34", "name=USB-SERIAL CH34"},
+		{"vid=12345", "error"},
+		{"pid=xyz", "error"},
+		{"vendor=1a86", "error"},
+		{"vid=", "error"},
+		{"serial=", "error"},
+		{"name= ", "error"},
+		{"name=CH34[", "error"},
+	}
+	for _, tt := range tests {
+		sel, err := ParseSelector(tt.s)
+		got := sel.String()
+		if err != nil {
+			got = "error"
+		}
+		if got != tt.want {
+			t.Errorf("ParseSelector(%q) = %q, %v, want %q", tt.s, sel, err, tt.want)
+		}
+	}
+}
+
+var (
+	ch340 = Port{Path: "/dev/ttyUSB0", Name: "USB-SERIAL CH340 (COM3)", VID: "1a86", PID: "7523"}
+	ftdi  = Port{Path: "/dev/ttyUSB1", Name: "USB Serial Port (COM4)", VID: "0403", PID: "6001", Serial: "A10K5XYZ"}
+	ch341 = Port{Path: "/dev/ttyUSB2", Name: "USB-SERIAL CH341A (COM5)", VID: "1a86", PID: "5523"}
+)
+
+func TestSelectorMatch(t *testing.T) {
+	tests := []struct {
+		s     string
+		ports []Port
+	}{
+		{"", []Port{ch340, ftdi, ch341}},
+		{"vid=1A86", []Port{ch340, ch341}},
+		{"vid=1a86,pid=7523", []Port{ch340}},
+		{"serial=A10K5XYZ", []Port{ftdi}},
+		{"serial=a10k5xyz", nil},
+		{"/dev/ttyUSB2", []Port{ch341}},
+		{"name=CH34", []Port{ch340, ch341}},
+		{"name=^USB-SERIAL CH34.A", []Port{ch341}},
+		{"name=\\(COM4\\)$", []Port{ftdi}},
+		{"vid=0403,name=CH34", nil},
+	}
+	for _, tt := range tests {
+		sel, err := ParseSelector(tt.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ports []Port
+		for _, p := range []Port{ch340, ftdi, ch341} {
+			if sel.Match(p) {
+				ports = append(ports, p)
+			}
+		}
+		if !reflect.DeepEqual(ports, tt.ports) {
+			t.Errorf("%q matches %v, want %v", tt.s, ports, tt.ports)
+		}
+	}
+}
+
+// ports of the system are replaced by list
+func setPorts(t *testing.T, list ...Port) {
+	t.Helper()
+	saved := listPorts
+	listPorts = func() []Port { return list }
+	t.Cleanup(func() { listPorts = saved })
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		ports []Port
+		s     string
+		paths []string
+	}{
+		// several ports match: all in order of the system
+		{[]Port{ch340, ftdi, ch341}, "vid=1a86", []string{"/dev/ttyUSB0", "/dev/ttyUSB2"}},
+		// empty selector prefers the adapters of DefaultSelector, otherwise every port
+		{[]Port{ftdi, ch341}, "", []string{"/dev/ttyUSB2"}},
+		{[]Port{ftdi}, "", []string{"/dev/ttyUSB1"}},
+		{nil, "", []string{}},
+		// path which is not listed is used as is, unless other properties are selected
+		{[]Port{ftdi}, "/dev/ttyS0", []string{"/dev/ttyS0"}},
+		{[]Port{ftdi}, "vid=1a86", []string{}},
+		{[]Port{ftdi}, "path=/dev/ttyS0,vid=1a86", []string{}},
+	}
+	for _, tt := range tests {
+		setPorts(t, tt.ports...)
+		sel, err := ParseSelector(tt.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if paths := Resolve(sel); !reflect.DeepEqual(paths, tt.paths) {
+			t.Errorf("Resolve(%q) of %v = %v, want %v", tt.s, tt.ports, paths, tt.paths)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package device
+
+// Watcher notifies changes of the serial ports (USB adapter plugged in or removed)
+type Watcher struct {
+	C <-chan struct{} // receives after changes (changes in short sequence may be merged)
+
+	c    chan struct{}
+	done chan struct{}
+}
+
+func newWatcher() *Watcher {
+	c := make(chan struct{}, 1)
+	return &Watcher{C: c, c: c, done: make(chan struct{})}
+}
+
+// signal change without blocking (pending change is not yet received)
+func (w *Watcher) changed() {
+	select {
+	case w.c <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops watching (C is closed)
+func (w *Watcher) Close() {
+	select {
+	case <-w.done:
+	default:
+		close(w.done)
+	}
+}
+
+func (w *Watcher) closed() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
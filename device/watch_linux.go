@@ -0,0 +1,58 @@
+package device
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+// kernel uevents (netlink) of tty devices, e.g. "add@/devices/.../ttyUSB0\0ACTION=add\0SUBSYSTEM=tty\0DEVNAME=ttyUSB0\0..."
+
+const ueventBufferSize = 8192
+
+// NewWatcher starts watching the USB serial ports (netlink uevents)
+func NewWatcher() (*Watcher, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("netlink socket failed: %v", err)
+	}
+	// receive timeout for checking Close
+	tv := syscall.Timeval{Sec: 1}
+	if err = syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err == nil {
+		err = syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: 1})
+	}
+	if err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("netlink bind failed: %v", err)
+	}
+	w := newWatcher()
+	go func() {
+		defer close(w.c)
+		defer syscall.Close(fd)
+		buf := make([]byte, ueventBufferSize)
+		for !w.closed() {
+			n, _, err := syscall.Recvfrom(fd, buf, 0)
+			if err != nil || n <= 0 {
+				continue
+			}
+			if isSerialPortEvent(buf[:n]) {
+				w.changed()
+			}
+		}
+	}()
+	return w, nil
+}
+
+// serial port added or removed
+func isSerialPortEvent(msg []byte) bool {
+	env := map[string]string{}
+	for _, field := range strings.Split(string(msg), "\x00") {
+		if i := strings.Index(field, "="); i > 0 {
+			env[field[:i]] = field[i+1:]
+		}
+	}
+	if env["SUBSYSTEM"] != "tty" || (env["ACTION"] != "add" && env["ACTION"] != "remove") {
+		return false
+	}
+	return strings.HasPrefix(env["DEVNAME"], "ttyUSB") || strings.HasPrefix(env["DEVNAME"], "ttyACM")
+}
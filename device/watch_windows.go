@@ -0,0 +1,39 @@
+package device
+
+import (
+	"strings"
+	"time"
+)
+
+// there is no device notification without window, therefore the port list is polled
+
+const watchInterval = 2 * time.Second
+
+// NewWatcher starts watching the COM ports (polling)
+func NewWatcher() (*Watcher, error) {
+	w := newWatcher()
+	go func() {
+		defer close(w.c)
+		last := portNames()
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+				if names := portNames(); names != last {
+					last = names
+					w.changed()
+				}
+			}
+		}
+	}()
+	return w, nil
+}
+
+func portNames() string {
+	Init()
+	defer Exit()
+	return strings.Join(GetDeviceClassPortNameList(), "\n")
+}
@@ -4,6 +4,10 @@ package main
 
 import (
 	"camcontrol/camera"
+	"camcontrol/camera/simulator"
+	"camcontrol/device"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -14,6 +18,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/asticode/go-astikit"
@@ -24,10 +29,29 @@ var (
 	VersionAstilectron string
 	VersionElectron    string
 
-	fs         = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
-	logfileArg = fs.String("LOGFILE", "log.txt", "the log filename")
-	comPortArg = fs.Int("COMPORT", -1, "COM port of camera")
-	profileArg = fs.String("PROFILE", "", "Overwrite last profile for pictures, e.g. 'church' or 'hut'")
+	fs           = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	logfileArg   = fs.String("LOGFILE", "log.txt", "the log filename")
+	comPortArg   = fs.Int("COMPORT", -1, "COM port of camera")
+	deviceArg    = fs.String("DEVICE", "", "Serial adapter of camera, e.g. 'vid=1a86,pid=7523', 'serial=A10K5XYZ', 'path=/dev/ttyUSB0' or 'name=USB-SERIAL CH34'")
+	baudArg      = fs.Uint("BAUD", 0, "Baud rate of serial camera, 0 = default of protocol")
+	dataBitsArg  = fs.Uint("DATABITS", 8, "Data bits of serial camera (5..8)")
+	stopBitsArg  = fs.Uint("STOPBITS", 1, "Stop bits of serial camera (1 or 2)")
+	parityArg    = fs.String("PARITY", "none", "Parity of serial camera: 'none', 'odd' or 'even'")
+	flowArg      = fs.String("FLOWCONTROL", "none", "Flow control of serial camera: 'none' or 'rtscts'")
+	timeoutArg   = fs.Duration("READTIMEOUT", 0, "Minimum wait for responses of serial camera, e.g. 1s for slow cameras, 0 = deadline of command")
+	delayArg     = fs.Duration("FRAMEDELAY", 0, "Minimum pause between frames to serial camera, e.g. 50ms")
+	addressArg   = fs.Uint("ADDRESS", 1, "Device address of camera (Pelco-D/P 1..255, VISCA 1..7)")
+	camerasArg   = fs.String("CAMERAS", "", "JSON file with several cameras (name, protocol, address, device, host, vendor, user, password, line parameters)")
+	transportArg = fs.String("TRANSPORT", "", "Transport of serial protocols: '' = serial port, 'tcp://host:port', 'rfc2217://host:port' (serial server) or 'udp://host:port' or 'simulator' (virtual camera)")
+	protocolArg  = fs.String("PROTOCOL", "tenveo-nv10u", "Camera protocol: "+strings.Join(camera.Drivers(), ", "))
+	hostArg      = fs.String("HOST", "", "Network address of camera (host[:port] or URL), e.g. for 'visca-ip', 'onvif' or 'http-cgi'")
+	vendorArg    = fs.String("VENDOR", "ptzoptics", "Camera vendor of 'http-cgi': 'ptzoptics', 'panasonic' or vendor of VENDORS file")
+	vendorsArg   = fs.String("VENDORS", "", "JSON file with URL templates of further 'http-cgi' vendors (see camera.LoadHTTPVendors)")
+	userArg      = fs.String("USER", "", "User name of network camera")
+	passwordArg  = fs.String("PASSWORD", "", "Password of network camera")
+	profileArg   = fs.String("PROFILE", "", "Overwrite last profile for pictures, e.g. 'church' or 'hut'")
+	powerOnArg   = fs.Bool("POWERON", false, "Switch camera on at startup")
+	standbyArg   = fs.Bool("STANDBY", false, "Switch camera to standby on exit")
 
 	heightOffset = 0
 	c            = &Context{size: astilectron.Size{Width: 1920, Height: 1080}}
@@ -37,10 +61,23 @@ const (
 	windowHeight = 380
 	windowWidth  = 350
 	viewHtml     = "view.html"
+	presetsJson  = "presets.json"
+	cameraName   = "Camera" // name of single camera (no CAMERAS file)
 	helpHtml     = "help.html"
 	controlHtml  = "control.html"
 )
 
+const (
+	focusSpeed = 0x02                   // slow manual focus (protocol range like zoom)
+	focusStep  = 300 * time.Millisecond // duration of manual focus per click
+)
+
+const (
+	positionInterval = time.Second      // position readout of view window while camera may move
+	positionFollow   = 10 * time.Second // duration of readout after command (e.g. move to preset)
+	positionRetry    = 10 * time.Second // camera not connected or without response to queries
+)
+
 // context required on events
 type Context struct {
 	dir        string
@@ -54,11 +91,21 @@ type Context struct {
 	profileIdx int
 	storeView  bool
 	size       astilectron.Size
-	cam        camera.Camera
+	cams       *camera.Registry
+	camName    string                         // name of selected camera
+	cam        camera.Camera                  // selected camera
+	presets    *camera.PresetStore            // positions of presets of selected camera
+	stores     map[string]*camera.PresetStore // positions of presets by camera name
 	a          *astilectron.Astilectron
 	mControl   *astilectron.MenuItem
 	mHelp      *astilectron.MenuItem
+	mCamera    []*astilectron.MenuItem // camera menu items depending on capabilities
 	wView      *astilectron.Window
+	mu         sync.Mutex        // connection states of watchers, selected camera and position of readout
+	connection map[string]string // last connection state message by camera name (connection-on/connection-off)
+	position   string            // last position message of selected camera
+	restoring  bool              // presets are restored in background (preset buttons are blocked)
+	moved      chan struct{}     // command sent to camera (position readout)
 	wControl   *astilectron.Window
 	wHelp      *astilectron.Window
 }
@@ -66,7 +113,14 @@ type Context struct {
 func main() {
 	// building with command line: astilectron-bundler.exe
 	// copy ui and license folder to the binary directory
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
 
+// run the application until the windows are closed (failures of astilectron are returned after the cleanup:
+// standby and close of the cameras)
+func run() error {
 	// parse given arguments
 	var err error
 	fs.Parse(os.Args[1:])
@@ -123,11 +177,51 @@ func main() {
 		c.profileIdx = c.getProfileIndex()
 	}
 
-	var camerr error
 	log.Printf("Use argument: COMPORT=%v\n", *comPortArg)
-	c.cam, camerr = camera.NewTenveoNV10U(*comPortArg, 1)
-	if camerr == nil {
-		defer c.cam.Close()
+	log.Printf("Use argument: DEVICE=%v\n", *deviceArg)
+	log.Printf("Use argument: BAUD=%v DATABITS=%v STOPBITS=%v PARITY=%v FLOWCONTROL=%v READTIMEOUT=%v FRAMEDELAY=%v\n",
+		*baudArg, *dataBitsArg, *stopBitsArg, *parityArg, *flowArg, *timeoutArg, *delayArg)
+	log.Printf("Use argument: PROTOCOL=%v TRANSPORT=%v\n", *protocolArg, *transportArg)
+	log.Printf("Use argument: HOST=%v\n", *hostArg)
+	log.Printf("Use argument: VENDOR=%v VENDORS=%v\n", *vendorArg, *vendorsArg)
+	if *vendorsArg != "" {
+		if err := camera.LoadHTTPVendors(*vendorsArg); err != nil {
+			log.Printf("LoadHTTPVendors failed: %v\n", err)
+		}
+	}
+	log.Printf("Use argument: USER=%v\n", *userArg)
+	log.Printf("Use argument: POWERON=%v STANDBY=%v\n", *powerOnArg, *standbyArg)
+	for _, name := range camera.Drivers() {
+		d, _ := camera.LookupDriver(name)
+		log.Printf("Camera driver %v: %v (%v)\n", name, d.Description, d.Schema)
+	}
+	for _, p := range device.Find(device.Selector{}) {
+		log.Printf("Serial port: %v\n", p)
+	}
+	log.Printf("Use argument: ADDRESS=%v CAMERAS=%v\n", *addressArg, *camerasArg)
+	var camerr error
+	c.cams, c.stores, camerr = openCameras()
+	defer c.cams.Close()
+	c.camName, c.cam = c.cams.Selected()
+	c.presets = c.stores[c.camName]
+	c.connection = map[string]string{}
+	for _, name := range c.cams.Names() {
+		cam := c.cams.Get(name)
+		if !cam.Capabilities().Power {
+			continue
+		}
+		if *powerOnArg {
+			if e := cam.Power(true); e != nil {
+				log.Printf("Power on of %v failed: %v\n", name, e)
+			}
+		}
+		if *standbyArg {
+			defer func(name string) {
+				if e := cam.Power(false); e != nil {
+					log.Printf("Standby of %v failed: %v\n", name, e)
+				}
+			}(name)
+		}
 	}
 
 	// enable debugging in VS code
@@ -141,7 +235,7 @@ func main() {
 		SingleInstance:     true,
 	})
 	if astierr != nil {
-		log.Fatal(fmt.Errorf("main: Creating astilectron app failed: %w", astierr))
+		return fmt.Errorf("main: Creating astilectron app failed: %w", astierr)
 	}
 	defer c.a.Close()
 
@@ -150,7 +244,7 @@ func main() {
 
 	// Start
 	if astierr = c.a.Start(); astierr != nil {
-		log.Fatal(fmt.Errorf("main: Starting astilectron app failed: %w", astierr))
+		return fmt.Errorf("main: Starting astilectron app failed: %w", astierr)
 	}
 
 	// use primary display (put window on right/bottom border)
@@ -160,7 +254,9 @@ func main() {
 	log.Printf("Display size: %v\n", c.size)
 
 	// create main window with menu
-	c.createViewWindow()
+	if astierr = c.createViewWindow(); astierr != nil {
+		return astierr
+	}
 
 	// it is required to adjust height on second call!? (new window on profile change)
 	heightOffset = 40
@@ -172,13 +268,246 @@ func main() {
 	if camerr != nil {
 		c.wView.SendMessage("io-error-" + camerr.Error())
 	}
+	for _, name := range c.cams.Names() {
+		if w, ok := c.cams.Get(name).(camera.ConnectionWatcher); ok {
+			if err := w.Watch(c.onConnection(name)); err != nil {
+				log.Printf("Failed to watch connection of %v: %v\n", name, err)
+			}
+		}
+	}
+	if c.cam != nil {
+		c.moved = make(chan struct{}, 1)
+		go c.readPosition()
+	}
 
 	// start event handling...
 	c.a.Wait()
+	return nil
+}
+
+// serial port of camera from arguments: adapter DEVICE and COMPORT (COMPORT overwrites the path) and line parameters,
+// line parameters of the camera entry overwrite the arguments (e.g. cameras on different ports)
+func serialConfig(selector string, portNo int, cfg cameraConfig) (camera.SerialConfig, error) {
+	config := camera.SerialConfig{
+		BaudRate:    *baudArg,
+		DataBits:    *dataBitsArg,
+		StopBits:    *stopBitsArg,
+		Parity:      *parityArg,
+		FlowControl: *flowArg,
+		ReadTimeout: *timeoutArg,
+		FrameDelay:  *delayArg,
+	}
+	if cfg.BaudRate != 0 {
+		config.BaudRate = cfg.BaudRate
+	}
+	if cfg.DataBits != 0 {
+		config.DataBits = cfg.DataBits
+	}
+	if cfg.StopBits != 0 {
+		config.StopBits = cfg.StopBits
+	}
+	if cfg.Parity != "" {
+		config.Parity = cfg.Parity
+	}
+	if cfg.FlowControl != "" {
+		config.FlowControl = cfg.FlowControl
+	}
+	var err error
+	if cfg.ReadTimeout != "" {
+		if config.ReadTimeout, err = time.ParseDuration(cfg.ReadTimeout); err != nil {
+			return config, fmt.Errorf("invalid read timeout: %v", err)
+		}
+	}
+	if cfg.FrameDelay != "" {
+		if config.FrameDelay, err = time.ParseDuration(cfg.FrameDelay); err != nil {
+			return config, fmt.Errorf("invalid frame delay: %v", err)
+		}
+	}
+	if config.Device, err = device.ParseSelector(selector); err != nil {
+		return config, err
+	}
+	if portNo != -1 {
+		config.Device.Path = device.PortPath(portNo)
+	}
+	if err = config.Validate(); err != nil {
+		return config, fmt.Errorf("invalid serial port parameter: %v", err)
+	}
+	return config, nil
+}
+
+// camera entry of CAMERAS file, empty values are taken from the program parameters
+type cameraConfig struct {
+	Name      string `json:"name"`
+	Protocol  string `json:"protocol"`
+	Address   uint   `json:"address"`
+	Transport string `json:"transport"`
+	ComPort   *int   `json:"comPort"`
+	Device    string `json:"device"`
+	Host      string `json:"host"`
+	Vendor    string `json:"vendor"`
+	User      string `json:"user"`
+	Password  string `json:"password"`
+	// line parameters of serial port (see program parameters), durations like "1s" or "50ms"
+	BaudRate    uint   `json:"baudRate"`
+	DataBits    uint   `json:"dataBits"`
+	StopBits    uint   `json:"stopBits"`
+	Parity      string `json:"parity"`
+	FlowControl string `json:"flowControl"`
+	ReadTimeout string `json:"readTimeout"`
+	FrameDelay  string `json:"frameDelay"`
+}
+
+// camera configurations of CAMERAS file (single camera of program parameters without file)
+func cameraConfigs() ([]cameraConfig, error) {
+	if *camerasArg == "" {
+		return []cameraConfig{{Name: cameraName}}, nil
+	}
+	data, err := ioutil.ReadFile(*camerasArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading cameras: %v", err)
+	}
+	var configs []cameraConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed reading cameras %v: %v", *camerasArg, err)
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no cameras in %v", *camerasArg)
+	}
+	names := map[string]bool{}
+	for _, cfg := range configs {
+		if err := validCameraName(cfg.Name); err != nil {
+			return nil, fmt.Errorf("invalid cameras %v: %v", *camerasArg, err)
+		}
+		if names[cfg.Name] {
+			return nil, fmt.Errorf("invalid cameras %v: camera '%v' is configured twice", *camerasArg, cfg.Name)
+		}
+		names[cfg.Name] = true
+	}
+	return configs, nil
+}
+
+// the camera name is part of the file name of its presets (presets-<name>.json)
+func validCameraName(name string) error {
+	if name == "" {
+		return fmt.Errorf("camera without name")
+	}
+	if strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return fmt.Errorf("camera name '%v' contains '/', '\\' or '..'", name)
+	}
+	return nil
+}
+
+// create cameras of configuration with their preset stores (cameras failing to open are not registered)
+func openCameras() (*camera.Registry, map[string]*camera.PresetStore, error) {
+	cams := camera.NewRegistry()
+	stores := map[string]*camera.PresetStore{}
+	configs, err := cameraConfigs()
+	if err != nil {
+		return cams, stores, err
+	}
+	var errs []string
+	for _, cfg := range configs {
+		if err := openCamera(cams, cfg); err != nil {
+			log.Printf("Camera '%v': %v\n", cfg.Name, err)
+			errs = append(errs, fmt.Sprintf("%v: %v", cfg.Name, err))
+		}
+		if cams.Get(cfg.Name) == nil {
+			continue
+		}
+		// presets.json of single camera, presets-<name>.json for several cameras
+		path := filepath.Join(c.dir, presetsJson)
+		if len(configs) > 1 {
+			path = filepath.Join(c.dir, "presets-"+cfg.Name+".json")
+		}
+		store, e := camera.LoadPresetStore(path)
+		if e != nil {
+			log.Printf("LoadPresetStore failed: %v\n", e)
+		}
+		stores[cfg.Name] = store
+	}
+	if len(errs) > 0 {
+		return cams, stores, errors.New(strings.Join(errs, "; "))
+	}
+	return cams, stores, nil
+}
+
+// create camera of configuration (missing values of program parameters) and register it
+func openCamera(cams *camera.Registry, cfg cameraConfig) error {
+	protocol, selector, host, vendor, user, password := cfg.Protocol, cfg.Device, cfg.Host, cfg.Vendor, cfg.User, cfg.Password
+	if protocol == "" {
+		protocol = *protocolArg
+	}
+	if selector == "" {
+		selector = *deviceArg
+	}
+	if host == "" {
+		host = *hostArg
+	}
+	if vendor == "" {
+		vendor = *vendorArg
+	}
+	if user == "" {
+		user = *userArg
+	}
+	if password == "" {
+		password = *passwordArg
+	}
+	transport := cfg.Transport
+	if transport == "" {
+		transport = *transportArg
+	}
+	address := cfg.Address
+	if address == 0 {
+		address = *addressArg
+	}
+	portNo := *comPortArg
+	if cfg.ComPort != nil {
+		portNo = *cfg.ComPort
+	}
+	if address > 0xff {
+		return fmt.Errorf("invalid camera address %d", address)
+	}
+	// serial port parameters are checked for serial drivers only (line parameters of serial server)
+	var t camera.Transport
+	var err error
+	if strings.EqualFold(transport, "simulator") {
+		// virtual camera of the protocol instead of hardware
+		sim, e := simulator.New(simulator.Protocol(protocol), byte(address))
+		if e != nil {
+			return e
+		}
+		t = sim.Transport()
+	} else if t, err = camera.ParseTransport(transport); err != nil {
+		return err
+	}
+	var port camera.SerialConfig
+	if d, ok := camera.LookupDriver(protocol); ok && d.Schema.Serial {
+		if port, err = serialConfig(selector, portNo, cfg); err != nil {
+			return err
+		}
+	}
+	log.Printf("Camera '%v': protocol %v address %v\n", cfg.Name, protocol, address)
+	cam, err := camera.New(protocol, camera.Options{
+		Serial:    port,
+		Transport: t,
+		Address:   byte(address),
+		Host:      host,
+		Vendor:    vendor,
+		User:      user,
+		Password:  password,
+	})
+	if cam == nil {
+		return err
+	}
+	if e := cams.Add(cfg.Name, cam); e != nil {
+		cam.Close()
+		return e
+	}
+	return err
 }
 
 // create main window with menu
-func (c *Context) createViewWindow() {
+func (c *Context) createViewWindow() error {
 	var err error
 	if c.wView, err = c.a.NewWindow(c.uiView, &astilectron.WindowOptions{
 		Width:       astikit.IntPtr(windowWidth),
@@ -188,7 +517,7 @@ func (c *Context) createViewWindow() {
 		Resizable:   astikit.BoolPtr(false),
 		AlwaysOnTop: astikit.BoolPtr(true),
 	}); err != nil {
-		log.Fatal(fmt.Errorf("create new view window failed: %w", err))
+		return fmt.Errorf("create new view window failed: %w", err)
 	}
 	c.wView.OnMessage(c.onWindowMessage)
 	// handle control window synchron in case of events...
@@ -210,7 +539,7 @@ func (c *Context) createViewWindow() {
 
 	// create window
 	if err = c.wView.Create(); err != nil {
-		log.Fatal(fmt.Errorf("main: creatig view window failed: %w", err))
+		return fmt.Errorf("main: creatig view window failed: %w", err)
 	}
 
 	viewMissing := !fileExist(c.uiView)
@@ -222,6 +551,8 @@ func (c *Context) createViewWindow() {
 		enabled = false
 	}
 
+	caps := c.capabilities()
+
 	// setup menus (dynamically depending on profiles)
 	menuOpt := []*astilectron.MenuItemOptions{
 		{
@@ -234,6 +565,33 @@ func (c *Context) createViewWindow() {
 					Type:    astilectron.MenuItemTypeCheckbox,
 					OnClick: c.onMenuControlClicked,
 				},
+				{
+					Label:   astikit.StrPtr("Restore presets"),
+					Enabled: astikit.BoolPtr(enabled && caps.AbsoluteMove && caps.PositionQuery),
+					Type:    astilectron.MenuItemTypeNormal,
+					OnClick: c.onMenuRestorePresetsClicked,
+				},
+				{
+					Type: astilectron.MenuItemTypeSeparator,
+				},
+				{
+					Label:   astikit.StrPtr("Power on"),
+					Enabled: astikit.BoolPtr(enabled && caps.Power),
+					Type:    astilectron.MenuItemTypeNormal,
+					OnClick: c.onMenuPowerClicked(true),
+				},
+				{
+					Label:   astikit.StrPtr("Standby"),
+					Enabled: astikit.BoolPtr(enabled && caps.Power),
+					Type:    astilectron.MenuItemTypeNormal,
+					OnClick: c.onMenuPowerClicked(false),
+				},
+				{
+					Label:   astikit.StrPtr("Reset camera"),
+					Enabled: astikit.BoolPtr(enabled && caps.Reset),
+					Type:    astilectron.MenuItemTypeNormal,
+					OnClick: c.onMenuResetClicked,
+				},
 			},
 		},
 	}
@@ -273,10 +631,193 @@ func (c *Context) createViewWindow() {
 	var m = c.a.NewMenu(menuOpt)
 	c.mControl, _ = m.Item(0, 0)
 	c.mHelp, _ = m.Item(2, 0)
+	c.mCamera = nil
+	for _, i := range []int{1, 3, 4, 5} {
+		if item, err := m.Item(0, i); err == nil {
+			c.mCamera = append(c.mCamera, item)
+		}
+	}
 
 	// create menu
 	if err = m.Create(); err != nil {
-		log.Fatal(fmt.Errorf("main: creatig menu failed: %w", err))
+		return fmt.Errorf("main: creatig menu failed: %w", err)
+	}
+
+	c.sendCameraState()
+	return nil
+}
+
+// focus near or far for a short time, manual focus is required (auto focus checkbox is reset)
+func (c *Context) focusStep(near bool) error {
+	if err := c.cam.FocusManual(); err != nil {
+		return err
+	}
+	if c.wControl != nil {
+		c.wControl.SendMessage("focus:manual")
+	}
+	var err error
+	if near {
+		err = c.cam.FocusNear(focusSpeed)
+	} else {
+		err = c.cam.FocusFar(focusSpeed)
+	}
+	if err != nil {
+		return err
+	}
+	time.Sleep(focusStep)
+	return c.cam.FocusStop()
+}
+
+// capabilities of camera (none without camera)
+func (c *Context) capabilities() camera.Capabilities {
+	if c.cam == nil {
+		return camera.Capabilities{}
+	}
+	return c.cam.Capabilities()
+}
+
+// number of views 0..9 of current profile supported by the presets of the camera
+func (c *Context) presetViews() int {
+	max := c.capabilities().MaxPresets
+	if max == 0 {
+		return 9
+	}
+	views := max - 9*c.profileIdx
+	if views < 0 {
+		return 0
+	}
+	if views > 9 {
+		return 9
+	}
+	return views
+}
+
+// connection state of camera changed (USB adapter plugged in or removed), only the selected camera is shown
+func (c *Context) onConnection(name string) func(bool) {
+	return func(connected bool) {
+		log.Printf("Camera %v connected: %v\n", name, connected)
+		msg := "connection-off"
+		if connected {
+			msg = "connection-on"
+		}
+		c.mu.Lock()
+		c.connection[name] = msg
+		c.mu.Unlock()
+		if selected, _ := c.cams.Selected(); selected == name {
+			c.wView.SendMessage(msg)
+		}
+	}
+}
+
+// query position of selected camera periodically after commands and show it in view window
+// (waits for next command if camera has no position query)
+func (c *Context) readPosition() {
+	last := time.Now()
+	for {
+		if time.Since(last) > positionFollow {
+			<-c.moved
+			last = time.Now()
+		}
+		select {
+		case <-c.moved:
+			last = time.Now()
+		default:
+		}
+		c.mu.Lock()
+		cam := c.cam
+		c.mu.Unlock()
+		if !cam.Capabilities().PositionQuery {
+			last = time.Time{}
+			continue
+		}
+		p, err := cam.Position()
+		if errors.Is(err, camera.ErrNotSupported) {
+			log.Printf("Position readout: %v\n", err)
+			last = time.Time{}
+			continue
+		}
+		if err != nil {
+			log.Printf("Position readout failed: %v\n", err)
+			time.Sleep(positionRetry)
+			continue
+		}
+		c.showPosition(cam, "position-"+p.String())
+		time.Sleep(positionInterval)
+	}
+}
+
+// show position message of camera in view window if the camera is still selected and the position changed
+func (c *Context) showPosition(cam camera.Camera, msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cam == c.cam && msg != c.position {
+		c.position = msg
+		c.wView.SendMessage(msg)
+	}
+}
+
+// select camera by name: update control window, view window and menu for capabilities of new camera
+func (c *Context) selectCamera(name string) error {
+	if err := c.cams.Select(name); err != nil {
+		return err
+	}
+	selected, cam := c.cams.Selected()
+	c.mu.Lock()
+	c.camName, c.cam = selected, cam
+	c.position = ""
+	c.mu.Unlock()
+	c.presets = c.stores[name]
+	log.Printf("Camera selected: %v\n", name)
+	c.storeViewOff(false)
+	if c.wControl != nil {
+		if caps, err := json.Marshal(c.capabilities()); err == nil {
+			c.wControl.SendMessage("capabilities-" + string(caps))
+		}
+	}
+	c.enableCameraMenu()
+	c.sendCameraState()
+	c.cameraMoved()
+	return nil
+}
+
+// enable camera menu entries supported by selected camera (restore presets, power on, standby, reset)
+func (c *Context) enableCameraMenu() {
+	if !fileExist(c.uiView) {
+		return
+	}
+	caps := c.capabilities()
+	enabled := []bool{caps.AbsoluteMove && caps.PositionQuery, caps.Power, caps.Power, caps.Reset}
+	for i, m := range c.mCamera {
+		m.SetEnabled(enabled[i])
+	}
+}
+
+// send cameras, selected camera, its connection, position and supported views to view window
+func (c *Context) sendCameraState() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if names := c.cams.Names(); len(names) > 1 {
+		if data, err := json.Marshal(names); err == nil {
+			c.wView.SendMessage("cameras-" + string(data))
+		}
+		c.wView.SendMessage("camera-" + c.camName)
+	}
+	if connection := c.connection[c.camName]; connection != "" {
+		c.wView.SendMessage(connection)
+	}
+	if c.position != "" {
+		c.wView.SendMessage(c.position)
+	} else {
+		c.wView.SendMessage("position-")
+	}
+	c.wView.SendMessage(fmt.Sprintf("views-%d", c.presetViews()))
+}
+
+// notify position readout about command (camera may move)
+func (c *Context) cameraMoved() {
+	select {
+	case c.moved <- struct{}{}:
+	default:
 	}
 }
 
@@ -459,6 +1000,78 @@ func (c *Context) getProfileIndex() int {
 	return -1
 }
 
+// restore presets menu handler: move camera to stored positions and save presets (e.g. replacement camera)
+func (c *Context) onMenuRestorePresetsClicked(e astilectron.Event) bool {
+	c.wView.SendMessage("close-dialog")
+	if c.cam == nil {
+		c.wView.SendMessage("io-error-no camera available")
+		return false
+	}
+	if !c.setRestoring(true) {
+		c.wView.SendMessage("io-error-presets are being restored")
+		return false
+	}
+	cam, store := c.cam, c.presets
+	go func() {
+		defer c.setRestoring(false)
+		log.Printf("Restore %d presets\n", store.Len())
+		if err := store.Restore(cam); err != nil {
+			log.Printf("Restore presets failed: %v\n", err)
+			c.wView.SendMessage("io-error-" + err.Error())
+		}
+		c.cameraMoved()
+	}()
+	return false
+}
+
+// set state of preset restore, false = restore is running already
+func (c *Context) setRestoring(restoring bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if restoring && c.restoring {
+		return false
+	}
+	c.restoring = restoring
+	return true
+}
+
+// presets are restored in background
+func (c *Context) isRestoring() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.restoring
+}
+
+// power menu handler (on or standby)
+func (c *Context) onMenuPowerClicked(on bool) astilectron.Listener {
+	return func(e astilectron.Event) bool {
+		c.wView.SendMessage("close-dialog")
+		if c.cam == nil {
+			c.wView.SendMessage("io-error-no camera available")
+			return false
+		}
+		if err := c.cam.Power(on); err != nil {
+			log.Printf("Camera io-error: %v", err)
+			c.wView.SendMessage("io-error-" + err.Error())
+		}
+		return false
+	}
+}
+
+// reset menu handler (restart camera)
+func (c *Context) onMenuResetClicked(e astilectron.Event) bool {
+	c.wView.SendMessage("close-dialog")
+	if c.cam == nil {
+		c.wView.SendMessage("io-error-no camera available")
+		return false
+	}
+	if err := c.cam.Reset(); err != nil {
+		log.Printf("Camera io-error: %v", err)
+		c.wView.SendMessage("io-error-" + err.Error())
+	}
+	return false
+}
+
 // help menu handler
 func (c *Context) onMenuHelpClicked(e astilectron.Event) bool {
 	helpActive := *e.MenuItemOptions.Checked
@@ -475,11 +1088,15 @@ func (c *Context) onMenuHelpClicked(e astilectron.Event) bool {
 				Minimizable: astikit.BoolPtr(true),
 				AlwaysOnTop: astikit.BoolPtr(false),
 			}); err != nil {
-				log.Fatal(fmt.Errorf("new help window failed: %w", err))
+				c.wHelp = nil
+				c.windowFailed(c.mHelp, fmt.Errorf("new help window failed: %w", err))
+				return false
 			}
 			c.wHelp.OnMessage(c.onWindowMessage)
 			if err = c.wHelp.Create(); err != nil {
-				log.Fatal(fmt.Errorf("create help window failed: %w", err))
+				c.wHelp = nil
+				c.windowFailed(c.mHelp, fmt.Errorf("create help window failed: %w", err))
+				return false
 			}
 			c.wHelp.On(astilectron.EventNameWindowEventClosed, func(e astilectron.Event) (deleteListener bool) {
 				if c.wHelp != nil && c.mHelp != nil {
@@ -500,6 +1117,15 @@ func (c *Context) onMenuHelpClicked(e astilectron.Event) bool {
 	return false
 }
 
+// help or control window failed: the error is shown in the view window and the menu entry is unchecked
+func (c *Context) windowFailed(item *astilectron.MenuItem, err error) {
+	log.Println(err)
+	c.wView.SendMessage("io-error-" + err.Error())
+	if item != nil {
+		item.SetChecked(false)
+	}
+}
+
 // about menu handler
 func (c *Context) onMenuAboutClicked(e astilectron.Event) bool {
 	c.wView.SendMessage("about")
@@ -516,7 +1142,12 @@ func (c *Context) onMenuProfileClicked(e astilectron.Event) bool {
 	}
 	c.profileIdx = c.getProfileIndex()
 	oldView := c.wView
-	c.createViewWindow()
+	if err := c.createViewWindow(); err != nil {
+		// without view window the application ends (cleanup of run)
+		log.Println(err)
+		c.a.Stop()
+		return true
+	}
 	oldView.Close()
 	return true
 }
@@ -539,11 +1170,18 @@ func (c *Context) onMenuControlClicked(e astilectron.Event) bool {
 				Minimizable: astikit.BoolPtr(false),
 				AlwaysOnTop: astikit.BoolPtr(true),
 			}); err != nil {
-				log.Fatal(fmt.Errorf("new control window failed: %w", err))
+				c.wControl = nil
+				c.windowFailed(c.mControl, fmt.Errorf("new control window failed: %w", err))
+				return false
 			}
 			c.wControl.OnMessage(c.onWindowMessage)
 			if err = c.wControl.Create(); err != nil {
-				log.Fatal(fmt.Errorf("create control window failed: %w", err))
+				c.wControl = nil
+				c.windowFailed(c.mControl, fmt.Errorf("create control window failed: %w", err))
+				return false
+			}
+			if caps, err := json.Marshal(c.capabilities()); err == nil {
+				c.wControl.SendMessage("capabilities-" + string(caps))
 			}
 			c.wControl.On(astilectron.EventNameWindowEventClosed, func(e astilectron.Event) (deleteListener bool) {
 				if c.wControl != nil && c.mControl != nil {
@@ -585,8 +1223,14 @@ func (c *Context) onWindowMessage(m *astilectron.EventMessage) interface{} {
 	var err error
 	var n int
 	m.Unmarshal(&elementId)
+	if c.cam == nil && (strings.HasPrefix(elementId, "ctrl_") || strings.HasPrefix(elementId, "view") ||
+		strings.HasPrefix(elementId, "focus_") || strings.HasPrefix(elementId, "image_")) {
+		log.Printf("No camera for event: %v\n", elementId)
+		c.wView.SendMessage("io-error-no camera available")
+		return nil
+	}
 	if strings.HasPrefix(elementId, "ctrl_") {
-		// up/down/left/righ/zoom...
+		// up/down/left/righ/zoom/diagonal...
 		idx := 6
 		if strings.HasPrefix(elementId, "ctrl_x") {
 			idx++
@@ -602,22 +1246,32 @@ func (c *Context) onWindowMessage(m *astilectron.EventMessage) interface{} {
 		fine := strings.HasPrefix(elementId, "ctrl_b") || strings.HasPrefix(elementId, "ctrl_xb")
 		log.Printf("Fine: %v\n", fine)
 		speed := byte(0x1f)
+		ptSpeed := 0.5 // normalized pan/tilt speed
 		if fine {
 			speed = byte(0x02)
+			ptSpeed = 0.01
 		}
 		switch ctrl {
 		case 1:
-			err = c.cam.Left()
+			err = c.cam.Left(ptSpeed)
 		case 2:
-			err = c.cam.Right()
+			err = c.cam.Right(ptSpeed)
 		case 3:
-			err = c.cam.Up()
+			err = c.cam.Up(ptSpeed)
 		case 4:
-			err = c.cam.Down()
+			err = c.cam.Down(ptSpeed)
 		case 5:
 			err = c.cam.ZoomIn(speed)
 		case 6:
 			err = c.cam.ZoomOut(speed)
+		case 7:
+			err = c.cam.Move(-ptSpeed, ptSpeed, 0)
+		case 8:
+			err = c.cam.Move(ptSpeed, ptSpeed, 0)
+		case 9:
+			err = c.cam.Move(-ptSpeed, -ptSpeed, 0)
+		case 10:
+			err = c.cam.Move(ptSpeed, -ptSpeed, 0)
 		}
 		if err == nil {
 			if fine {
@@ -625,12 +1279,13 @@ func (c *Context) onWindowMessage(m *astilectron.EventMessage) interface{} {
 			} else {
 				time.Sleep(500 * time.Millisecond)
 			}
-			if ctrl <= 4 {
+			if ctrl <= 4 || ctrl >= 7 {
 				c.cam.PtStop()
 			} else {
 				c.cam.ZoomStop()
 			}
 		}
+		c.cameraMoved()
 	} else if strings.HasPrefix(elementId, "view") {
 		// view select 1..9
 		n, err = strconv.Atoi(elementId[4:])
@@ -640,6 +1295,15 @@ func (c *Context) onWindowMessage(m *astilectron.EventMessage) interface{} {
 			log.Printf("Failed to convert to int %v %v\n", elementId, err)
 			return nil
 		}
+		if n > c.presetViews() {
+			log.Printf("Preset of %v not supported by camera (max %d)\n", elementId, c.capabilities().MaxPresets)
+			return nil
+		}
+		if c.isRestoring() {
+			log.Printf("Preset %v blocked while restoring presets\n", elementId)
+			c.wView.SendMessage("io-error-presets are being restored")
+			return nil
+		}
 		store := c.storeView
 		c.storeViewOff(false)
 		if c.profileIdx > 0 {
@@ -649,11 +1313,56 @@ func (c *Context) onWindowMessage(m *astilectron.EventMessage) interface{} {
 		if store {
 			log.Printf("Save Preset: %d\n", preset)
 			err = c.cam.PresetSave(preset)
+			if err == nil && c.capabilities().PositionQuery {
+				if e := c.presets.Record(c.cam, preset); e != nil {
+					log.Printf("Preset position not stored: %v\n", e)
+				}
+			}
 		} else {
 			log.Printf("Activate Preset: %d\n", preset)
 			err = c.cam.PresetSelect(preset)
 		}
+		c.cameraMoved()
 
+	} else if strings.HasPrefix(elementId, "focus_") {
+		// focus near/far (switches to manual focus), auto/manual, one push
+		switch elementId {
+		case "focus_near", "focus_far":
+			err = c.focusStep(elementId == "focus_near")
+		case "focus_auto:true":
+			err = c.cam.FocusAuto()
+		case "focus_auto:false":
+			err = c.cam.FocusManual()
+		case "focus_onepush":
+			err = c.cam.FocusOnePush()
+		default:
+			log.Printf("Unknown event: %v\n", elementId)
+		}
+	} else if strings.HasPrefix(elementId, "image_") {
+		// image settings: iris/gain steps, exposure, backlight and white balance
+		switch {
+		case elementId == "image_iris_open":
+			err = c.cam.IrisOpen()
+		case elementId == "image_iris_close":
+			err = c.cam.IrisClose()
+		case elementId == "image_gain_up":
+			err = c.cam.GainUp()
+		case elementId == "image_gain_down":
+			err = c.cam.GainDown()
+		case elementId == "image_wb_trigger":
+			err = c.cam.WhiteBalanceTrigger()
+		case strings.HasPrefix(elementId, "image_exposure:"):
+			err = c.cam.Exposure(camera.ExposureMode(strings.TrimPrefix(elementId, "image_exposure:")))
+		case strings.HasPrefix(elementId, "image_backlight:"):
+			err = c.cam.Backlight(elementId == "image_backlight:true")
+		case strings.HasPrefix(elementId, "image_wb:"):
+			err = c.cam.WhiteBalance(camera.WhiteBalanceMode(strings.TrimPrefix(elementId, "image_wb:")))
+		default:
+			log.Printf("Unknown event: %v\n", elementId)
+		}
+	} else if strings.HasPrefix(elementId, "camera:") {
+		// camera selection of view window
+		err = c.selectCamera(strings.TrimPrefix(elementId, "camera:"))
 	} else if strings.HasPrefix(elementId, "store") {
 		// store on/off
 		c.storeView = strings.EqualFold(elementId, "store:true")